@@ -17,49 +17,426 @@ type LogicalReplicationSpec struct {
 
 	//
 	Subscription SubscriptionSpec `json:"subscription"`
+
+	// How often are both databases checked for drift from the desired state,
+	// defaults to the operator's --resync-interval
+	ResyncInterval *metav1.Duration `json:"resyncInterval,omitempty"`
 }
 
 // PublicationSpec defines the publisher connection information including
 // name of the publication and the connection secret.
 type PublicationSpec struct {
-	// Name of the publication on the publisher's side
+	// Name of the publication on the publisher's side.
+	// Using a previous publication kept in status rolls the subscriber back to it,
+	// its tables are renamed back and its subscription is enabled again.
 	Name string `json:"name"`
 
 	// The secret name of to connect to the publisher's database
 	SecretName string `json:"secretName"`
+
+	// Strategy of switching to a different publication, defaults to Rename
+	SwitchStrategy PublicationSwitchStrategy `json:"switchStrategy,omitempty"`
+
+	// Dedicated role the subscription connects to the publisher as,
+	// the publisher's Secret accounts are used when not set
+	ReplicationRole *ReplicationRoleSpec `json:"replicationRole,omitempty"`
+
+	// Connection of the subscriber's server to the publisher when it reaches the publisher
+	// differently than the operator, it's used only in the subscription's connection string
+	SubscriberConnection *SubscriberConnectionSpec `json:"subscriberConnection,omitempty"`
+}
+
+// SubscriberConnectionSpec overrides the publisher's Secret in the subscription's connection string.
+type SubscriberConnectionSpec struct {
+	// Host of the publisher as seen from the subscriber, defaults to the Secret's host
+	Host string `json:"host,omitempty"`
+
+	// Port of the publisher as seen from the subscriber, defaults to the Secret's port
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	Port int32 `json:"port,omitempty"`
+
+	// Additional libpq parameters, e.g. sslmode, the user, password and database can't be overridden
+	Params map[string]string `json:"params,omitempty"`
+}
+
+// ReplicationRoleSpec defines a login role with REPLICATION and SELECT on the published tables
+// created on the publisher with the admin account of the publisher's Secret. Its password
+// is kept in an operator owned Secret and the role is dropped with the LogicalReplication.
+type ReplicationRoleSpec struct {
+	// Name of the role, defaults to a name derived from the namespace and name of the LogicalReplication
+	Name string `json:"name,omitempty"`
+
+	// Interval of rotating the role's password, the password isn't rotated when not set
+	RotationInterval *metav1.Duration `json:"rotationInterval,omitempty"`
 }
 
+// PublicationSwitchStrategy defines how the subscriber moves to a new publication.
+// Rename renames tables of the old publication and disables its subscription right away.
+// BlueGreen keeps the old subscription live until all tables of the new publication
+// are synchronized, then it swaps consumer facing views and disables the old subscription.
+// Tables published by both are renamed like with Rename and the old subscription is disabled
+// when the switch starts, consumer views keep selecting from the renamed tables until the swap.
+// +kubebuilder:validation:Enum=Rename;BlueGreen
+type PublicationSwitchStrategy string
+
+var (
+	PublicationSwitchRename    = PublicationSwitchStrategy("Rename")
+	PublicationSwitchBlueGreen = PublicationSwitchStrategy("BlueGreen")
+)
+
 // SubscriptionSpec defines the database where the replication would be set up.
 type SubscriptionSpec struct {
 	// The secret name of to connect to the dababase where the replication
 	// would be set up.
 	SecretName string `json:"secretName"`
+
+	// Constraints of the published tables created also on the subscriber,
+	// constraints dropped on the publisher are kept on the subscriber
+	Constraints ConstraintsSpec `json:"constraints,omitempty"`
+
+	// Synchronization of sequences used by the published tables
+	Sequences SequencesSpec `json:"sequences,omitempty"`
+
+	// How are default expressions of the published columns created
+	// on the subscriber, defaults to Copy. Defaults of existing tables
+	// are altered to follow the policy.
+	ColumnDefaults ColumnDefaultsPolicy `json:"columnDefaults,omitempty"`
+
+	// Settings of individual published tables on the subscriber
+	Tables []TableSpec `json:"tables,omitempty"`
+
+	// Retention of tables and subscriptions of previous publications,
+	// they are kept forever when not set
+	Retention *RetentionSpec `json:"retention,omitempty"`
+
+	// Names of subscriptions and tables of previous publications
+	Naming NamingSpec `json:"naming,omitempty"`
+}
+
+// NamingSpec defines names of objects created by the operator. All names are
+// shortened to fit into PostgreSQL identifiers without colliding with each other.
+type NamingSpec struct {
+	// Naming of renamed tables of a previous publication, defaults to Suffix
+	Tables TableNamingStrategy `json:"tables,omitempty"`
+
+	// Schema of the renamed tables with the Schema strategy
+	ArchiveSchema string `json:"archiveSchema,omitempty"`
+
+	// Prefix of the subscription and its replication slot, they are named
+	// by the publication when empty. Changing the prefix of a running
+	// replication renames its subscription, the slot keeps its previous name.
+	SubscriptionPrefix string `json:"subscriptionPrefix,omitempty"`
+}
+
+// TableNamingStrategy defines how tables of a previous publication are renamed.
+// Suffix and Prefix add the publication name to the table name, Schema moves
+// the table into the archive schema and HashedSuffix adds a short hash of the publication name.
+// +kubebuilder:validation:Enum=Suffix;Prefix;Schema;HashedSuffix
+type TableNamingStrategy string
+
+var (
+	TableNamingSuffix       = TableNamingStrategy("Suffix")
+	TableNamingPrefix       = TableNamingStrategy("Prefix")
+	TableNamingSchema       = TableNamingStrategy("Schema")
+	TableNamingHashedSuffix = TableNamingStrategy("HashedSuffix")
+)
+
+// RetentionSpec defines when tables and the disabled subscription of a previous publication
+// are dropped. A version is dropped when either of the limits is exceeded.
+type RetentionSpec struct {
+	// Number of previous publications kept on the subscriber
+	KeepVersions *int32 `json:"keepVersions,omitempty"`
+
+	// Time after which a previous publication is dropped
+	DropAfter *metav1.Duration `json:"dropAfter,omitempty"`
+}
+
+// TableSpec customizes how a published table is created on the subscriber.
+type TableSpec struct {
+	// Schema of the published table
+	Schema string `json:"schema"`
+
+	// Name of the published table
+	Name string `json:"name"`
+
+	// Partitioning of the subscriber's table independent of the publisher's layout,
+	// partitioned publisher's table has to be published via its partition root
+	Partitioning *PartitioningSpec `json:"partitioning,omitempty"`
+
+	// Consumer facing view of the replicated table
+	View *ViewSpec `json:"view,omitempty"`
+}
+
+// ViewSpec defines a view exposing the replicated table to consumers.
+// The view is recreated whenever its definition or the replicated table changes.
+type ViewSpec struct {
+	// Schema of the view
+	Schema string `json:"schema"`
+
+	// Name of the view, defaults to the table name
+	Name string `json:"name,omitempty"`
+
+	// Role owning the view
+	Owner string `json:"owner,omitempty"`
+
+	// Columns selected by the view, all replicated columns are selected when empty
+	Columns []ViewColumnSpec `json:"columns,omitempty"`
+
+	// Row filter, SQL expression used in the WHERE clause of the view
+	Filter string `json:"filter,omitempty"`
+}
+
+// ViewColumnSpec defines a column selected by the view.
+type ViewColumnSpec struct {
+	// Column of the replicated table
+	Name string `json:"name"`
+
+	// Name of the column in the view, defaults to the table's column name
+	As string `json:"as,omitempty"`
+
+	// Type the column is cast to
+	Cast string `json:"cast,omitempty"`
+}
+
+// PartitioningSpec defines partitioned layout of the subscriber's table.
+// Range partitions are created by the operator for rolling time intervals,
+// list and range partitioned tables get also a default partition. Rows of
+// a new range partition already caught by the default partition are moved
+// into the new partition.
+// +kubebuilder:validation:XValidation:rule="self.strategy != 'Hash' || has(self.modulus)",message="modulus is required by Hash partitioning"
+type PartitioningSpec struct {
+	// Partitioning strategy
+	Strategy PartitionStrategy `json:"strategy"`
+
+	// Partition key, comma separated column names, range partitioning
+	// takes a single column
+	// +kubebuilder:validation:MinLength=1
+	Key string `json:"key"`
+
+	// Time interval covered by a range partition, defaults to Day
+	Interval PartitionInterval `json:"interval,omitempty"`
+
+	// Number of range partitions created ahead of the current interval
+	Premake int32 `json:"premake,omitempty"`
+
+	// Number of past range partitions kept, older partitions are dropped.
+	// Zero keeps all partitions.
+	Retention int32 `json:"retention,omitempty"`
+
+	// Partitions of a list partitioned table
+	Lists []ListPartitionSpec `json:"lists,omitempty"`
+
+	// Number of partitions of a hash partitioned table
+	// +kubebuilder:validation:Minimum=1
+	Modulus int32 `json:"modulus,omitempty"`
+}
+
+// +kubebuilder:validation:Enum=Range;List;Hash
+type PartitionStrategy string
+
+var (
+	PartitionStrategyRange = PartitionStrategy("Range")
+	PartitionStrategyList  = PartitionStrategy("List")
+	PartitionStrategyHash  = PartitionStrategy("Hash")
+)
+
+// +kubebuilder:validation:Enum=Day;Week;Month;Year
+type PartitionInterval string
+
+// ListPartitionSpec defines a partition of a list partitioned table.
+type ListPartitionSpec struct {
+	// Suffix of the partition name of at most 53 bytes, the table's name is shortened to keep it
+	Name string `json:"name"`
+
+	// Values of the partition key stored in the partition
+	Values []string `json:"values"`
+}
+
+// ColumnDefaultsPolicy defines handling of column default expressions.
+// Copy creates the defaults as they are on the publisher, Strip creates columns
+// without defaults and CopyIfResolvable copies only defaults whose functions,
+// sequences and types exist on the subscriber.
+// +kubebuilder:validation:Enum=Copy;Strip;CopyIfResolvable
+type ColumnDefaultsPolicy string
+
+var (
+	ColumnDefaultsCopy             = ColumnDefaultsPolicy("Copy")
+	ColumnDefaultsStrip            = ColumnDefaultsPolicy("Strip")
+	ColumnDefaultsCopyIfResolvable = ColumnDefaultsPolicy("CopyIfResolvable")
+)
+
+// ConstraintsSpec selects kinds of constraints replicated from the publisher.
+type ConstraintsSpec struct {
+	// Replicate CHECK constraints
+	Check bool `json:"check,omitempty"`
+
+	// Replicate UNIQUE constraints, primary keys are replicated only with foreign keys
+	Unique bool `json:"unique,omitempty"`
+
+	// Replicate FOREIGN KEY constraints between published tables,
+	// primary keys of the tables are replicated with them as the referenced keys must exist
+	ForeignKeys ForeignKeysSpec `json:"foreignKeys,omitempty"`
+}
+
+// ForeignKeysSpec defines how foreign keys are created on the subscriber.
+// Apply order on the subscriber does not have to match the publisher's
+// transaction order, so foreign keys usually need to be deferrable or not valid.
+type ForeignKeysSpec struct {
+	// Replicate FOREIGN KEY constraints
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Create foreign keys as NOT VALID, existing rows are not checked
+	NotValid bool `json:"notValid,omitempty"`
+
+	// Create foreign keys as DEFERRABLE INITIALLY DEFERRED
+	Deferrable bool `json:"deferrable,omitempty"`
+}
+
+// SequencesSpec defines synchronization of sequences used by the published tables.
+// Logical replication does not replicate sequences, without the synchronization
+// a promoted subscriber would generate already used values.
+type SequencesSpec struct {
+	// Periodically copy last values of the publisher's sequences to the subscriber
+	Sync bool `json:"sync,omitempty"`
+
+	// How often are the sequences synchronized, defaults to 5 minutes
+	SyncInterval *metav1.Duration `json:"syncInterval,omitempty"`
 }
 
 // last successfully reconciled values
 type ReconciledValues struct {
-	PublicationName        string                `json:"publicationName"`
-	PublicationSecretHash  string                `json:"publicationSecretHash"`
-	SubscriptionSecretHash string                `json:"subscriptionSecretHash"`
-	Tables                 []replication.PgTable `json:"tables"`
+	PublicationName        string                `json:"publicationName,omitempty"`
+	SubscriptionName       string                `json:"subscriptionName,omitempty"`
+	PublicationSecretHash  string                `json:"publicationSecretHash,omitempty"`
+	SubscriptionSecretHash string                `json:"subscriptionSecretHash,omitempty"`
+	Tables                 []replication.PgTable `json:"tables,omitempty"`
+}
+
+// SequenceStatus reports synchronization of a sequence used by the published tables
+type SequenceStatus struct {
+	Schema string `json:"schema"`
+	Name   string `json:"name"`
+
+	// Last value of the publisher's sequence
+	LastValue int64 `json:"lastValue"`
+
+	// Difference between the publisher's and the subscriber's last value
+	// found before the last synchronization
+	Lag int64 `json:"lag"`
+}
+
+// RetainedVersion is a previous publication whose tables are kept on the subscriber
+type RetainedVersion struct {
+	// Name of the publication and its disabled subscription
+	PublicationName string `json:"publicationName"`
+
+	// Name of the subscription when it has been created with a different prefix
+	SubscriptionName string `json:"subscriptionName,omitempty"`
+
+	// Tables of the publication on the subscriber
+	Tables []replication.PgTable `json:"tables,omitempty"`
+
+	// Published names of the renamed tables in the order of Tables,
+	// empty when the tables kept their names
+	OriginalTables []replication.PgTable `json:"originalTables,omitempty"`
+
+	// Time the subscriber switched to the next publication
+	RetiredAt metav1.Time `json:"retiredAt"`
+}
+
+// SubscriptionErrors are error counters of the subscription's workers
+type SubscriptionErrors struct {
+	// Errors while applying changes
+	ApplyErrors int64 `json:"applyErrors,omitempty"`
+
+	// Errors during the initial table synchronization
+	SyncErrors int64 `json:"syncErrors,omitempty"`
+
+	// Time the apply worker was last found not running or its error counters grew,
+	// the subscription stays degraded until the worker keeps running for a while
+	LastErrorTime *metav1.Time `json:"lastErrorTime,omitempty"`
+}
+
+// DriftStatus summarizes what was corrected on the databases
+type DriftStatus struct {
+	// Time of the last successful reconciliation
+	LastResyncTime *metav1.Time `json:"lastResyncTime,omitempty"`
+
+	// Changes made by the reconciliation, empty when nothing has drifted
+	Corrections []string `json:"corrections,omitempty"`
+}
+
+// RetryStatus reports retries of a failing reconciliation
+type RetryStatus struct {
+	// Consecutive failed reconciliations with the same reason
+	Attempts int32 `json:"attempts"`
+
+	// Time of the next attempt, not set when the reconciliation waits for a change of the spec or a Secret
+	NextRetryTime *metav1.Time `json:"nextRetryTime,omitempty"`
+}
+
+// ReplicationRoleStatus reports the role the subscription connects as
+type ReplicationRoleStatus struct {
+	// Name of the role on the publisher
+	Name string `json:"name"`
+
+	// Secret with the role's credentials
+	SecretName string `json:"secretName"`
+
+	// Time the role's password was last set
+	PasswordRotatedAt metav1.Time `json:"passwordRotatedAt,omitempty"`
 }
 
 // LogicalReplicationStatus defines the observed state of LogicalReplication
 type LogicalReplicationStatus struct {
-	ReplicationStatus ReplicationStatus `json:"replicationStatus"`
-	ReconciledValues  ReconciledValues  `json:"reconciledValues"`
+	ReplicationStatus ReplicationStatus `json:"replicationStatus,omitempty"`
+	ReconciledValues  ReconciledValues  `json:"reconciledValues,omitempty"`
+	Sequences         []SequenceStatus  `json:"sequences,omitempty"`
+	RetainedVersions  []RetainedVersion `json:"retainedVersions,omitempty"`
+
+	// Error counters seen by the last health check of the subscription
+	SubscriptionErrors SubscriptionErrors `json:"subscriptionErrors,omitempty"`
+
+	// Changes made by the last successful reconciliation
+	Drift DriftStatus `json:"drift,omitempty"`
+
+	// Retries of the failing reconciliation, not set when the last reconciliation succeeded
+	Retry *RetryStatus `json:"retry,omitempty"`
+
+	// Dedicated role of the subscription, not set without spec.publication.replicationRole
+	ReplicationRole *ReplicationRoleStatus `json:"replicationRole,omitempty"`
+
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
+// ConditionDegraded is true when the subscription exists but doesn't replicate,
+// its apply worker isn't running or its workers report new errors
+var ConditionDegraded = "Degraded"
+
+var (
+	ReasonHealthy          = "Healthy"
+	ReasonWorkerNotRunning = "WorkerNotRunning"
+	ReasonApplyErrors      = "ApplyErrors"
+	ReasonSyncErrors       = "SyncErrors"
+)
+
 // Status of the replication
 type ReplicationStatus struct {
 	Phase   ReplicationPhase `json:"phase,omitempty"`
 	Reason  string           `json:"reason,omitempty"`
 	Message string           `json:"message,omitempty"`
+	// remediation of the failure when it's recognized
+	Hint string `json:"hint,omitempty"`
 }
 
-// +kubebuilder:validation:Enum=Pending;Replicating;Failed;Unknown
+// +kubebuilder:validation:Enum=Pending;Replicating;Switching;Failed;Unknown
 type ReplicationPhase string
 
+var ReplicationPhaseReplicating = ReplicationPhase("Replicating")
+var ReplicationPhaseSwitching = ReplicationPhase("Switching")
 var ReplicationPhaseFailed = ReplicationPhase("Failed")
 
 // +kubebuilder:object:root=true
@@ -6,15 +6,91 @@ package v1alpha1
 
 import (
 	"github.com/RedHatInsights/pg-replication-operator/internal/replication"
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConstraintsSpec) DeepCopyInto(out *ConstraintsSpec) {
+	*out = *in
+	out.ForeignKeys = in.ForeignKeys
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConstraintsSpec.
+func (in *ConstraintsSpec) DeepCopy() *ConstraintsSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ConstraintsSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DriftStatus) DeepCopyInto(out *DriftStatus) {
+	*out = *in
+	if in.LastResyncTime != nil {
+		in, out := &in.LastResyncTime, &out.LastResyncTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Corrections != nil {
+		in, out := &in.Corrections, &out.Corrections
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DriftStatus.
+func (in *DriftStatus) DeepCopy() *DriftStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(DriftStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ForeignKeysSpec) DeepCopyInto(out *ForeignKeysSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ForeignKeysSpec.
+func (in *ForeignKeysSpec) DeepCopy() *ForeignKeysSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ForeignKeysSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ListPartitionSpec) DeepCopyInto(out *ListPartitionSpec) {
+	*out = *in
+	if in.Values != nil {
+		in, out := &in.Values, &out.Values
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ListPartitionSpec.
+func (in *ListPartitionSpec) DeepCopy() *ListPartitionSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ListPartitionSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *LogicalReplication) DeepCopyInto(out *LogicalReplication) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
+	in.Spec.DeepCopyInto(&out.Spec)
 	in.Status.DeepCopyInto(&out.Status)
 }
 
@@ -71,8 +147,13 @@ func (in *LogicalReplicationList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *LogicalReplicationSpec) DeepCopyInto(out *LogicalReplicationSpec) {
 	*out = *in
-	out.Publication = in.Publication
-	out.Subscription = in.Subscription
+	in.Publication.DeepCopyInto(&out.Publication)
+	in.Subscription.DeepCopyInto(&out.Subscription)
+	if in.ResyncInterval != nil {
+		in, out := &in.ResyncInterval, &out.ResyncInterval
+		*out = new(v1.Duration)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LogicalReplicationSpec.
@@ -90,6 +171,37 @@ func (in *LogicalReplicationStatus) DeepCopyInto(out *LogicalReplicationStatus)
 	*out = *in
 	out.ReplicationStatus = in.ReplicationStatus
 	in.ReconciledValues.DeepCopyInto(&out.ReconciledValues)
+	if in.Sequences != nil {
+		in, out := &in.Sequences, &out.Sequences
+		*out = make([]SequenceStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.RetainedVersions != nil {
+		in, out := &in.RetainedVersions, &out.RetainedVersions
+		*out = make([]RetainedVersion, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	in.SubscriptionErrors.DeepCopyInto(&out.SubscriptionErrors)
+	in.Drift.DeepCopyInto(&out.Drift)
+	if in.Retry != nil {
+		in, out := &in.Retry, &out.Retry
+		*out = new(RetryStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ReplicationRole != nil {
+		in, out := &in.ReplicationRole, &out.ReplicationRole
+		*out = new(ReplicationRoleStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LogicalReplicationStatus.
@@ -102,9 +214,56 @@ func (in *LogicalReplicationStatus) DeepCopy() *LogicalReplicationStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NamingSpec) DeepCopyInto(out *NamingSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NamingSpec.
+func (in *NamingSpec) DeepCopy() *NamingSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(NamingSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PartitioningSpec) DeepCopyInto(out *PartitioningSpec) {
+	*out = *in
+	if in.Lists != nil {
+		in, out := &in.Lists, &out.Lists
+		*out = make([]ListPartitionSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PartitioningSpec.
+func (in *PartitioningSpec) DeepCopy() *PartitioningSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PartitioningSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PublicationSpec) DeepCopyInto(out *PublicationSpec) {
 	*out = *in
+	if in.ReplicationRole != nil {
+		in, out := &in.ReplicationRole, &out.ReplicationRole
+		*out = new(ReplicationRoleSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SubscriberConnection != nil {
+		in, out := &in.SubscriberConnection, &out.SubscriberConnection
+		*out = new(SubscriberConnectionSpec)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PublicationSpec.
@@ -137,6 +296,42 @@ func (in *ReconciledValues) DeepCopy() *ReconciledValues {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReplicationRoleSpec) DeepCopyInto(out *ReplicationRoleSpec) {
+	*out = *in
+	if in.RotationInterval != nil {
+		in, out := &in.RotationInterval, &out.RotationInterval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReplicationRoleSpec.
+func (in *ReplicationRoleSpec) DeepCopy() *ReplicationRoleSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ReplicationRoleSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReplicationRoleStatus) DeepCopyInto(out *ReplicationRoleStatus) {
+	*out = *in
+	in.PasswordRotatedAt.DeepCopyInto(&out.PasswordRotatedAt)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReplicationRoleStatus.
+func (in *ReplicationRoleStatus) DeepCopy() *ReplicationRoleStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ReplicationRoleStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ReplicationStatus) DeepCopyInto(out *ReplicationStatus) {
 	*out = *in
@@ -152,9 +347,170 @@ func (in *ReplicationStatus) DeepCopy() *ReplicationStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RetainedVersion) DeepCopyInto(out *RetainedVersion) {
+	*out = *in
+	if in.Tables != nil {
+		in, out := &in.Tables, &out.Tables
+		*out = make([]replication.PgTable, len(*in))
+		copy(*out, *in)
+	}
+	if in.OriginalTables != nil {
+		in, out := &in.OriginalTables, &out.OriginalTables
+		*out = make([]replication.PgTable, len(*in))
+		copy(*out, *in)
+	}
+	in.RetiredAt.DeepCopyInto(&out.RetiredAt)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RetainedVersion.
+func (in *RetainedVersion) DeepCopy() *RetainedVersion {
+	if in == nil {
+		return nil
+	}
+	out := new(RetainedVersion)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RetentionSpec) DeepCopyInto(out *RetentionSpec) {
+	*out = *in
+	if in.KeepVersions != nil {
+		in, out := &in.KeepVersions, &out.KeepVersions
+		*out = new(int32)
+		**out = **in
+	}
+	if in.DropAfter != nil {
+		in, out := &in.DropAfter, &out.DropAfter
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RetentionSpec.
+func (in *RetentionSpec) DeepCopy() *RetentionSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RetentionSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RetryStatus) DeepCopyInto(out *RetryStatus) {
+	*out = *in
+	if in.NextRetryTime != nil {
+		in, out := &in.NextRetryTime, &out.NextRetryTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RetryStatus.
+func (in *RetryStatus) DeepCopy() *RetryStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RetryStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SequenceStatus) DeepCopyInto(out *SequenceStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SequenceStatus.
+func (in *SequenceStatus) DeepCopy() *SequenceStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SequenceStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SequencesSpec) DeepCopyInto(out *SequencesSpec) {
+	*out = *in
+	if in.SyncInterval != nil {
+		in, out := &in.SyncInterval, &out.SyncInterval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SequencesSpec.
+func (in *SequencesSpec) DeepCopy() *SequencesSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SequencesSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SubscriberConnectionSpec) DeepCopyInto(out *SubscriberConnectionSpec) {
+	*out = *in
+	if in.Params != nil {
+		in, out := &in.Params, &out.Params
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SubscriberConnectionSpec.
+func (in *SubscriberConnectionSpec) DeepCopy() *SubscriberConnectionSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SubscriberConnectionSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SubscriptionErrors) DeepCopyInto(out *SubscriptionErrors) {
+	*out = *in
+	if in.LastErrorTime != nil {
+		in, out := &in.LastErrorTime, &out.LastErrorTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SubscriptionErrors.
+func (in *SubscriptionErrors) DeepCopy() *SubscriptionErrors {
+	if in == nil {
+		return nil
+	}
+	out := new(SubscriptionErrors)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SubscriptionSpec) DeepCopyInto(out *SubscriptionSpec) {
 	*out = *in
+	out.Constraints = in.Constraints
+	in.Sequences.DeepCopyInto(&out.Sequences)
+	if in.Tables != nil {
+		in, out := &in.Tables, &out.Tables
+		*out = make([]TableSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Retention != nil {
+		in, out := &in.Retention, &out.Retention
+		*out = new(RetentionSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	out.Naming = in.Naming
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SubscriptionSpec.
@@ -166,3 +522,63 @@ func (in *SubscriptionSpec) DeepCopy() *SubscriptionSpec {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TableSpec) DeepCopyInto(out *TableSpec) {
+	*out = *in
+	if in.Partitioning != nil {
+		in, out := &in.Partitioning, &out.Partitioning
+		*out = new(PartitioningSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.View != nil {
+		in, out := &in.View, &out.View
+		*out = new(ViewSpec)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TableSpec.
+func (in *TableSpec) DeepCopy() *TableSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(TableSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ViewColumnSpec) DeepCopyInto(out *ViewColumnSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ViewColumnSpec.
+func (in *ViewColumnSpec) DeepCopy() *ViewColumnSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ViewColumnSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ViewSpec) DeepCopyInto(out *ViewSpec) {
+	*out = *in
+	if in.Columns != nil {
+		in, out := &in.Columns, &out.Columns
+		*out = make([]ViewColumnSpec, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ViewSpec.
+func (in *ViewSpec) DeepCopy() *ViewSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ViewSpec)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -3,7 +3,9 @@ package main
 import (
 	"crypto/tls"
 	"flag"
+	"fmt"
 	"os"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
@@ -21,6 +23,7 @@ import (
 
 	replicationv1alpha1 "github.com/RedHatInsights/pg-replication-operator/api/v1alpha1"
 	"github.com/RedHatInsights/pg-replication-operator/internal/controller"
+	"github.com/RedHatInsights/pg-replication-operator/internal/replication"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -42,6 +45,12 @@ func main() {
 	var probeAddr string
 	var secureMetrics bool
 	var enableHTTP2 bool
+	var resyncInterval time.Duration
+	var dbMaxOpenConns int
+	var dbIdleTimeout time.Duration
+	var dbTimeouts replication.Timeouts
+	var breakerThreshold int
+	var breakerProbeInterval time.Duration
 	var tlsOpts []func(*tls.Config)
 	flag.StringVar(&metricsAddr, "metrics-bind-address", "0", "The address the metrics endpoint binds to. "+
 		"Use :8443 for HTTPS or :8080 for HTTP, or leave as 0 to disable the metrics service.")
@@ -53,6 +62,22 @@ func main() {
 		"If set, the metrics endpoint is served securely via HTTPS. Use --metrics-secure=false to use HTTP instead.")
 	flag.BoolVar(&enableHTTP2, "enable-http2", false,
 		"If set, HTTP/2 will be enabled for the metrics and webhook servers")
+	flag.DurationVar(&resyncInterval, "resync-interval", time.Minute,
+		"How often are replications checked for drift and their metrics refreshed. "+
+			"Overridden by spec.resyncInterval, 0 disables the periodic resync.")
+	flag.IntVar(&dbMaxOpenConns, "db-max-open-conns", 4,
+		"Maximum number of open connections to each database, shared by replications using the same credentials.")
+	flag.DurationVar(&dbIdleTimeout, "db-idle-timeout", 10*time.Minute,
+		"How long are unused database connections kept open.")
+	flag.DurationVar(&dbTimeouts.Statement, "statement-timeout", time.Minute,
+		"statement_timeout of the operator's database sessions, 0 keeps the database's setting.")
+	flag.DurationVar(&dbTimeouts.Lock, "lock-timeout", 5*time.Second,
+		"lock_timeout of the operator's database sessions so that DDL doesn't wait behind long-running transactions, "+
+			"0 keeps the database's setting.")
+	flag.IntVar(&breakerThreshold, "host-failure-threshold", 3,
+		"Consecutive connection failures after which replications stop connecting to the database host until it recovers.")
+	flag.DurationVar(&breakerProbeInterval, "host-probe-interval", 30*time.Second,
+		"How often are unavailable database hosts probed for recovery.")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -61,6 +86,17 @@ func main() {
 
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
+	if breakerThreshold < 1 {
+		setupLog.Error(fmt.Errorf("--host-failure-threshold must be at least 1, got %d", breakerThreshold),
+			"invalid flags")
+		os.Exit(1)
+	}
+	if breakerProbeInterval <= 0 {
+		setupLog.Error(fmt.Errorf("--host-probe-interval must be positive, got %s", breakerProbeInterval),
+			"invalid flags")
+		os.Exit(1)
+	}
+
 	// if the enable-http2 flag is false (the default), http/2 should be disabled
 	// due to its vulnerabilities. More specifically, disabling http/2 will
 	// prevent from being vulnerable to the HTTP/2 Stream Cancellation and
@@ -128,9 +164,25 @@ func main() {
 		os.Exit(1)
 	}
 
+	connections := replication.NewConnectionManager(dbMaxOpenConns, dbIdleTimeout, dbTimeouts)
+	if err = mgr.Add(connections); err != nil {
+		setupLog.Error(err, "unable to set up database connections")
+		os.Exit(1)
+	}
+
+	breaker := controller.NewHostBreaker(breakerThreshold, breakerProbeInterval)
+	if err = mgr.Add(breaker); err != nil {
+		setupLog.Error(err, "unable to set up database host probes")
+		os.Exit(1)
+	}
+
 	if err = (&controller.LogicalReplicationReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:         mgr.GetClient(),
+		Scheme:         mgr.GetScheme(),
+		Recorder:       mgr.GetEventRecorderFor("logicalreplication-controller"),
+		ResyncInterval: resyncInterval,
+		Connections:    connections,
+		Breaker:        breaker,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "LogicalReplication")
 		os.Exit(1)
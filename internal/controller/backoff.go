@@ -0,0 +1,70 @@
+package controller
+
+import (
+	"errors"
+	"time"
+)
+
+// retryPolicy defines when a failed reconciliation is retried,
+// the delay doubles with each consecutive failure up to max
+type retryPolicy struct {
+	base time.Duration
+	max  time.Duration
+	// the reconciliation isn't retried, it waits for a change of a watched object
+	watched bool
+}
+
+var defaultRetryPolicy = retryPolicy{base: 5 * time.Second, max: 5 * time.Minute}
+
+// schema errors need a change of the spec, which triggers reconciliation,
+// or a change on the publisher which can be only polled
+var schemaRetryPolicy = retryPolicy{base: time.Minute, max: 30 * time.Minute}
+
+// terminal database errors need a fix of the database, they are polled as well
+var terminalRetryPolicy = schemaRetryPolicy
+
+// degraded subscriptions are checked again until their workers recover
+var degradedRetryPolicy = retryPolicy{base: 10 * time.Second, max: 5 * time.Minute}
+
+var retryPolicies = map[ReplicationErrorReason]retryPolicy{
+	SecretError:             {watched: true},
+	HostUnavailable:         {watched: true},
+	ConnectError:            defaultRetryPolicy,
+	PublicationError:        schemaRetryPolicy,
+	PublicationTablesError:  schemaRetryPolicy,
+	SubscriptionSchemaError: schemaRetryPolicy,
+	SubscriptionTablesError: schemaRetryPolicy,
+}
+
+func retryPolicyFor(reason ReplicationErrorReason) retryPolicy {
+	if policy, ok := retryPolicies[reason]; ok {
+		return policy
+	}
+	return defaultRetryPolicy
+}
+
+// retryPolicyForError slows down retries of terminal errors
+func retryPolicyForError(err error) retryPolicy {
+	var replerr ReplicationError
+	if !errors.As(err, &replerr) {
+		return defaultRetryPolicy
+	}
+	policy := retryPolicyFor(replerr.Reason)
+	if !replerr.Transient && !policy.watched {
+		return terminalRetryPolicy
+	}
+	return policy
+}
+
+// delay before the next attempt, zero when the reconciliation isn't retried
+func (p retryPolicy) delay(attempts int32) time.Duration {
+	if p.watched {
+		return 0
+	}
+
+	delay := p.base
+	for n := int32(1); n < attempts && delay < p.max; n++ {
+		delay *= 2
+	}
+	return min(delay, p.max)
+}
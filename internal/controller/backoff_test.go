@@ -0,0 +1,26 @@
+package controller
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("retryPolicy", func() {
+	It("should double the delay up to max", func() {
+		policy := retryPolicyFor(ConnectError)
+		Expect(policy.delay(1)).To(Equal(5 * time.Second))
+		Expect(policy.delay(3)).To(Equal(20 * time.Second))
+		Expect(policy.delay(100)).To(Equal(5 * time.Minute))
+	})
+
+	It("should wait for watched objects", func() {
+		Expect(retryPolicyFor(SecretError).delay(1)).To(BeZero())
+	})
+
+	It("should poll schema errors slowly", func() {
+		Expect(retryPolicyFor(SubscriptionTablesError).delay(1)).To(Equal(time.Minute))
+		Expect(retryPolicyFor(SubscriptionViewError).delay(1)).To(Equal(defaultRetryPolicy.base))
+	})
+})
@@ -0,0 +1,163 @@
+package controller
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	replicationv1alpha1 "github.com/RedHatInsights/pg-replication-operator/api/v1alpha1"
+	"github.com/RedHatInsights/pg-replication-operator/internal/replication"
+)
+
+// timeout of the TCP connection probing an unavailable host
+const probeTimeout = 5 * time.Second
+
+// HostBreaker tracks health of database hosts shared by all replications,
+// once connections to a host fail repeatedly its replications stop connecting
+// until the host answers a probe, then they are reconciled again
+type HostBreaker struct {
+	// consecutive connection failures opening the host's circuit
+	Threshold int
+	// interval of probing hosts with open circuit
+	ProbeInterval time.Duration
+
+	mu     sync.Mutex
+	hosts  map[string]*hostState
+	events chan event.GenericEvent
+}
+
+type hostState struct {
+	failures  int
+	open      bool
+	nextProbe time.Time
+	// replications short-circuited while the circuit is open
+	waiting map[types.NamespacedName]struct{}
+}
+
+func NewHostBreaker(threshold int, probeInterval time.Duration) *HostBreaker {
+	return &HostBreaker{
+		Threshold:     threshold,
+		ProbeInterval: probeInterval,
+		hosts:         make(map[string]*hostState),
+		events:        make(chan event.GenericEvent),
+	}
+}
+
+func hostKey(creds replication.DatabaseCredentials) string {
+	return net.JoinHostPort(creds.Host, creds.Port)
+}
+
+// Allow reports whether the replication may connect to the host, otherwise
+// it's reconciled once the host recovers, nil breaker allows all connections
+func (b *HostBreaker) Allow(host string, name types.NamespacedName) bool {
+	if b == nil {
+		return true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	state, ok := b.hosts[host]
+	if !ok || !state.open {
+		return true
+	}
+	state.waiting[name] = struct{}{}
+	return false
+}
+
+// Success closes the host's circuit
+func (b *HostBreaker) Success(host string) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.hosts, host)
+}
+
+// Failure counts the host's connection failure, it reports whether the circuit opened
+func (b *HostBreaker) Failure(host string) bool {
+	if b == nil {
+		return false
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	state, ok := b.hosts[host]
+	if !ok {
+		state = &hostState{waiting: make(map[types.NamespacedName]struct{})}
+		b.hosts[host] = state
+	}
+	state.failures++
+	if state.open || state.failures < b.Threshold {
+		return false
+	}
+	state.open = true
+	state.nextProbe = time.Now().Add(b.ProbeInterval)
+	return true
+}
+
+// Events of replications to reconcile after their host recovered
+func (b *HostBreaker) Events() <-chan event.GenericEvent {
+	return b.events
+}
+
+// Start probes hosts with open circuit until the context is done, it implements manager.Runnable
+func (b *HostBreaker) Start(ctx context.Context) error {
+	ticker := time.NewTicker(b.ProbeInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case now := <-ticker.C:
+			for _, name := range b.probe(ctx, now) {
+				lr := &replicationv1alpha1.LogicalReplication{}
+				lr.Namespace, lr.Name = name.Namespace, name.Name
+				select {
+				case b.events <- event.GenericEvent{Object: lr}:
+				case <-ctx.Done():
+					return nil
+				}
+			}
+		}
+	}
+}
+
+// probe connects to hosts due for a probe and returns replications of recovered hosts
+func (b *HostBreaker) probe(ctx context.Context, now time.Time) []types.NamespacedName {
+	b.mu.Lock()
+	due := make([]string, 0)
+	for host, state := range b.hosts {
+		if state.open && !now.Before(state.nextProbe) {
+			due = append(due, host)
+		}
+	}
+	b.mu.Unlock()
+
+	recovered := make([]types.NamespacedName, 0)
+	dialer := net.Dialer{Timeout: probeTimeout}
+	for _, host := range due {
+		conn, err := dialer.DialContext(ctx, "tcp", host)
+
+		b.mu.Lock()
+		state, ok := b.hosts[host]
+		switch {
+		case !ok:
+		case err != nil:
+			state.nextProbe = time.Now().Add(b.ProbeInterval)
+		default:
+			conn.Close()
+			for name := range state.waiting {
+				recovered = append(recovered, name)
+			}
+			delete(b.hosts, host)
+		}
+		b.mu.Unlock()
+	}
+	return recovered
+}
@@ -0,0 +1,79 @@
+package controller
+
+import (
+	"context"
+	"net"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/RedHatInsights/pg-replication-operator/internal/replication"
+)
+
+var _ = Describe("HostBreaker", func() {
+	name := types.NamespacedName{Namespace: "default", Name: "replication"}
+
+	It("should open the circuit after repeated failures", func() {
+		breaker := NewHostBreaker(2, time.Minute)
+		Expect(breaker.Failure("db:5432")).To(BeFalse())
+		Expect(breaker.Allow("db:5432", name)).To(BeTrue())
+		Expect(breaker.Failure("db:5432")).To(BeTrue())
+		Expect(breaker.Allow("db:5432", name)).To(BeFalse())
+		Expect(breaker.Allow("other:5432", name)).To(BeTrue())
+	})
+
+	It("should reset failures on success", func() {
+		breaker := NewHostBreaker(2, time.Minute)
+		breaker.Failure("db:5432")
+		breaker.Success("db:5432")
+		Expect(breaker.Failure("db:5432")).To(BeFalse())
+	})
+
+	It("should allow all connections without breaker", func() {
+		var breaker *HostBreaker
+		Expect(breaker.Failure("db:5432")).To(BeFalse())
+		Expect(breaker.Allow("db:5432", name)).To(BeTrue())
+	})
+
+	It("should reconcile waiting replications once the host answers", func() {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).NotTo(HaveOccurred())
+		defer listener.Close()
+		host := listener.Addr().String()
+
+		breaker := NewHostBreaker(1, time.Minute)
+		breaker.Failure(host)
+		Expect(breaker.Allow(host, name)).To(BeFalse())
+
+		Expect(breaker.probe(context.Background(), time.Now())).To(BeEmpty())
+		Expect(breaker.probe(context.Background(), time.Now().Add(time.Minute))).To(ConsistOf(name))
+		Expect(breaker.Allow(host, name)).To(BeTrue())
+	})
+
+	It("should count a failed host once per reconciliation", func() {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).NotTo(HaveOccurred())
+		creds := replication.DatabaseCredentials{User: "user", Password: "password", DatabaseName: "data"}
+		creds.Host, creds.Port, err = net.SplitHostPort(listener.Addr().String())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(listener.Close()).To(Succeed())
+
+		breaker := NewHostBreaker(2, time.Minute)
+		iteration := NewLogicalReplicationIteration(nil, nil, nil, breaker, context.Background(),
+			reconcile.Request{NamespacedName: name})
+		_, err = iteration.connectDB("database", creds)
+		Expect(err).To(HaveOccurred())
+		_, err = iteration.connectDB("database/admin", creds.Admin())
+		Expect(err).To(HaveOccurred())
+		Expect(breaker.Allow(hostKey(creds), name)).To(BeTrue())
+
+		iteration = NewLogicalReplicationIteration(nil, nil, nil, breaker, context.Background(),
+			reconcile.Request{NamespacedName: name})
+		_, err = iteration.connectDB("database", creds)
+		Expect(err).To(HaveOccurred())
+		Expect(breaker.Allow(hostKey(creds), name)).To(BeFalse())
+	})
+})
@@ -1,5 +1,10 @@
 package controller
 
+import (
+	"errors"
+	"strings"
+)
+
 type ReplicationErrorReason string
 
 var SecretError ReplicationErrorReason = "SecretError"
@@ -9,16 +14,117 @@ var SubscriptionError ReplicationErrorReason = "SubscriptionError"
 var PublicationTablesError ReplicationErrorReason = "PublicationTablesError"
 var SubscriptionSchemaError ReplicationErrorReason = "SubscriptionSchemaError"
 var SubscriptionTablesError ReplicationErrorReason = "SubscriptionTablesError"
+var SubscriptionDependenciesError ReplicationErrorReason = "SubscriptionDependenciesError"
+var SubscriptionSequencesError ReplicationErrorReason = "SubscriptionSequencesError"
+var SubscriptionViewError ReplicationErrorReason = "SubscriptionViewError"
+var ReplicationRoleError ReplicationErrorReason = "ReplicationRoleError"
+
+// reasons of database errors classified by their SQLSTATE,
+// they replace the reason of the failed step
+var AuthenticationFailed ReplicationErrorReason = "AuthenticationFailed"
+var DatabaseNotFound ReplicationErrorReason = "DatabaseNotFound"
+var PermissionDenied ReplicationErrorReason = "PermissionDenied"
+var UndefinedObject ReplicationErrorReason = "UndefinedObject"
+var ObjectInUse ReplicationErrorReason = "ObjectInUse"
+var LockTimeout ReplicationErrorReason = "LockTimeout"
+var StatementTimeout ReplicationErrorReason = "StatementTimeout"
+var SerializationFailure ReplicationErrorReason = "SerializationFailure"
+var ConnectionLost ReplicationErrorReason = "ConnectionLost"
+var TooManyConnections ReplicationErrorReason = "TooManyConnections"
+var WalLevelNotLogical ReplicationErrorReason = "WalLevelNotLogical"
+var SlotLimitReached ReplicationErrorReason = "SlotLimitReached"
+var DiskFull ReplicationErrorReason = "DiskFull"
+
+// HostUnavailable short-circuits reconciliation of a host failing to connect repeatedly
+var HostUnavailable ReplicationErrorReason = "HostUnavailable"
 
 type ReplicationError struct {
 	Reason ReplicationErrorReason
 	Err    error
+	// SQLSTATE of the database error, empty for other errors
+	Code string
+	// the error may go away without a change of the spec or the databases
+	Transient bool
+	// remediation of the error for humans
+	Hint string
 }
 
 func (re ReplicationError) Error() string {
 	return re.Err.Error()
 }
 
+func (re ReplicationError) Unwrap() error {
+	return re.Err
+}
+
+// NewReplicationError wraps the error of a reconciliation step, database errors
+// are classified by their SQLSTATE, other errors are considered transient
 func NewReplicationError(reason ReplicationErrorReason, err error) ReplicationError {
-	return ReplicationError{Reason: reason, Err: err}
+	replerr := ReplicationError{Reason: reason, Err: err, Transient: true}
+
+	var sqlerr interface{ SQLState() string }
+	if !errors.As(err, &sqlerr) {
+		return replerr
+	}
+	replerr.Code = sqlerr.SQLState()
+	if class, ok := classifyError(replerr.Code, err.Error()); ok {
+		replerr.Reason = class.reason
+		replerr.Transient = class.transient
+		replerr.Hint = class.hint
+	}
+	return replerr
+}
+
+type errorClass struct {
+	reason    ReplicationErrorReason
+	transient bool
+	hint      string
+}
+
+var sqlStateClasses = map[string]errorClass{
+	"28000": {AuthenticationFailed, false, "check the user and password in the credentials Secret and pg_hba.conf of the database"},
+	"28P01": {AuthenticationFailed, false, "check the user and password in the credentials Secret"},
+	"3D000": {DatabaseNotFound, false, "check the database name in the credentials Secret"},
+	"42501": {PermissionDenied, false, "grant the database user privileges to the replicated objects or use a user with the required role"},
+	"42P01": {UndefinedObject, false, "create the missing table or fix its name in the spec"},
+	"42704": {UndefinedObject, false, "create the missing object or fix its name in the spec"},
+	"55006": {ObjectInUse, true, "the object is used by another session, the operation is retried"},
+	"55P03": {LockTimeout, true, "a lock is held by another session, the operation is retried"},
+	"57014": {StatementTimeout, true, "the statement was canceled, check long running transactions holding locks"},
+	"40001": {SerializationFailure, true, "a concurrent transaction conflicted, the operation is retried"},
+	"40P01": {SerializationFailure, true, "a deadlock with a concurrent transaction was detected, the operation is retried"},
+	"53300": {TooManyConnections, true, "raise max_connections or max_wal_senders of the database or reduce its connections"},
+	"53400": {SlotLimitReached, false, "raise max_replication_slots of the publisher or max_active_replication_origins of the subscriber, or drop unused slots"},
+	"53100": {DiskFull, false, "free or add disk space to the database"},
+	"57P01": {ConnectionLost, true, "the database is shutting down, the operation is retried"},
+	"57P03": {ConnectionLost, true, "the database is starting up, the operation is retried"},
+}
+
+// errors of the publisher are relayed by the subscriber with a generic
+// SQLSTATE, they are recognized by their message
+var messageClasses = []struct {
+	substr string
+	class  errorClass
+}{
+	{"wal_level", errorClass{WalLevelNotLogical, false, "set wal_level to logical on the publisher and restart it"}},
+	{"replication slots are in use", errorClass{SlotLimitReached, false, "raise max_replication_slots of the publisher or drop unused slots"}},
+	{"free replication state slot", errorClass{SlotLimitReached, false, "raise max_active_replication_origins (max_replication_slots before PostgreSQL 18) of the subscriber"}},
+}
+
+// connection exceptions of SQLSTATE class 08
+var connectionExceptionClass = errorClass{ConnectionLost, true, "the connection to the database failed, the operation is retried"}
+
+func classifyError(code, message string) (errorClass, bool) {
+	for _, mc := range messageClasses {
+		if strings.Contains(message, mc.substr) {
+			return mc.class, true
+		}
+	}
+	if class, ok := sqlStateClasses[code]; ok {
+		return class, true
+	}
+	if strings.HasPrefix(code, "08") {
+		return connectionExceptionClass, true
+	}
+	return errorClass{}, false
 }
@@ -0,0 +1,46 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("NewReplicationError", func() {
+	It("should classify database errors by SQLSTATE", func() {
+		err := NewReplicationError(SubscriptionError, &pq.Error{Code: "42501", Message: "permission denied for table cities"})
+		Expect(err.Reason).To(Equal(PermissionDenied))
+		Expect(err.Code).To(Equal("42501"))
+		Expect(err.Transient).To(BeFalse())
+		Expect(err.Hint).NotTo(BeEmpty())
+	})
+
+	It("should classify wrapped database errors", func() {
+		err := NewReplicationError(SubscriptionTablesError,
+			fmt.Errorf("renaming table: %w", &pq.Error{Code: "55P03", Message: "could not obtain lock"}))
+		Expect(err.Reason).To(Equal(LockTimeout))
+		Expect(err.Transient).To(BeTrue())
+	})
+
+	It("should recognize errors relayed from the publisher", func() {
+		err := NewReplicationError(SubscriptionError, &pq.Error{Code: "XX000",
+			Message: "could not create replication slot: ERROR: logical decoding requires wal_level >= logical"})
+		Expect(err.Reason).To(Equal(WalLevelNotLogical))
+		Expect(err.Transient).To(BeFalse())
+	})
+
+	It("should keep the reason of other errors", func() {
+		err := NewReplicationError(PublicationError, errors.New("publication not found"))
+		Expect(err.Reason).To(Equal(PublicationError))
+		Expect(err.Transient).To(BeTrue())
+		Expect(err.Hint).To(BeEmpty())
+	})
+
+	It("should slow down retries of terminal errors", func() {
+		err := NewReplicationError(ConnectError, &pq.Error{Code: "28P01"})
+		Expect(retryPolicyForError(err)).To(Equal(terminalRetryPolicy))
+	})
+})
@@ -0,0 +1,43 @@
+package controller
+
+// reasons of Normal events emitted for changes made on the subscriber,
+// steady-state reconciliations don't change anything so they don't emit events
+var (
+	CreatedSchemaEvent         = "CreatedSchema"
+	CreatedExtensionEvent      = "CreatedExtension"
+	CreatedTypeEvent           = "CreatedType"
+	AddedEnumLabelsEvent       = "AddedEnumLabels"
+	CreatedSequenceEvent       = "CreatedSequence"
+	CreatedTableEvent          = "CreatedTable"
+	AlteredColumnDefaultEvent  = "AlteredColumnDefault"
+	CreatedPartitionEvent      = "CreatedPartition"
+	DroppedPartitionEvent      = "DroppedPartition"
+	CreatedConstraintEvent     = "CreatedConstraint"
+	CreatedViewEvent           = "CreatedView"
+	RenamedTableEvent          = "RenamedTable"
+	RestoredTableEvent         = "RestoredTable"
+	DroppedTableEvent          = "DroppedTable"
+	CreatedSubscriptionEvent   = "CreatedSubscription"
+	CreatedSlotEvent           = "CreatedReplicationSlot"
+	AlteredSubscriptionEvent   = "AlteredSubscription"
+	RenamedSubscriptionEvent   = "RenamedSubscription"
+	RefreshedSubscriptionEvent = "RefreshedSubscription"
+	DisabledSubscriptionEvent  = "DisabledSubscription"
+	DroppedSubscriptionEvent   = "DroppedSubscription"
+	SwitchedPublicationEvent   = "SwitchedPublication"
+	RolledBackEvent            = "RolledBack"
+
+	CreatedReplicationRoleEvent = "CreatedReplicationRole"
+	AlteredReplicationRoleEvent = "AlteredReplicationRole"
+	GrantedReplicationRoleEvent = "GrantedReplicationRole"
+	RotatedPasswordEvent        = "RotatedPassword"
+	DroppedReplicationRoleEvent = "DroppedReplicationRole"
+)
+
+// reasons of events reporting the replication's health, failures are reported
+// as Warning events with ReplicationErrorReason
+var (
+	ReconcileErrorEvent = "ReconcileError"
+	DegradedEvent       = "Degraded"
+	RecoveredEvent      = "Recovered"
+)
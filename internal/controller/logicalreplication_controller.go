@@ -4,16 +4,31 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	"github.com/go-logr/logr"
 	"github.com/go-viper/mapstructure/v2"
+	"github.com/prometheus/client_golang/prometheus"
 
 	replicationv1alpha1 "github.com/RedHatInsights/pg-replication-operator/api/v1alpha1"
 	"github.com/RedHatInsights/pg-replication-operator/internal/replication"
@@ -22,13 +37,21 @@ import (
 // LogicalReplicationReconciler reconciles a LogicalReplication object
 type LogicalReplicationReconciler struct {
 	client.Client
-	Scheme *runtime.Scheme
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+	// default interval of checking the databases for drift
+	ResyncInterval time.Duration
+	// connection pools shared across reconciliations
+	Connections *replication.ConnectionManager
+	// health of database hosts shared by all replications
+	Breaker *HostBreaker
 }
 
 // +kubebuilder:rbac:groups=replication.console.redhat.com,resources=logicalreplications,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=replication.console.redhat.com,resources=logicalreplications/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=replication.console.redhat.com,resources=logicalreplications/finalizers,verbs=update
-// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;update
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -45,113 +68,548 @@ func (r *LogicalReplicationReconciler) Reconcile(ctx context.Context, req ctrl.R
 	// Get the LogicalReplication object from the API
 	lr := &replicationv1alpha1.LogicalReplication{}
 	if err := r.Client.Get(ctx, req.NamespacedName, lr); err != nil {
+		if client.IgnoreNotFound(err) == nil {
+			deleteMetrics(req.Namespace, req.Name)
+		}
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
 
-	iteration := NewLogicalReplicationIteration(r.Client, ctx, req)
+	iteration := NewLogicalReplicationIteration(r.Client, r.Recorder, r.Connections, r.Breaker, ctx, req)
+
+	if !lr.DeletionTimestamp.IsZero() {
+		return r.finalize(ctx, lr, iteration)
+	}
+
+	if lr.Spec.Publication.ReplicationRole != nil && controllerutil.AddFinalizer(lr, replicationRoleFinalizer) {
+		if err := r.Update(ctx, lr); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
 
 	err := iteration.Iterate(lr)
 	if err != nil {
-		statusErr := r.setFailedStatus(ctx, lr, err)
-		return ctrl.Result{Requeue: true}, statusErr
+		requeueAfter, statusErr := r.setFailedStatus(ctx, lr, err)
+		return ctrl.Result{RequeueAfter: requeueAfter}, statusErr
+	}
+
+	retryAfter, err := r.setReplicatingStatus(ctx, lr, iteration)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// degraded subscription is checked again with backoff
+	if retryAfter > 0 {
+		return ctrl.Result{RequeueAfter: retryAfter}, nil
+	}
+
+	return ctrl.Result{RequeueAfter: iteration.requeueAfter(r.ResyncInterval)}, nil
+}
+
+// finalize cleans up the publisher before the LogicalReplication is deleted
+func (r *LogicalReplicationReconciler) finalize(ctx context.Context,
+	obj *replicationv1alpha1.LogicalReplication, iteration *LogicalReplicationIteration) (ctrl.Result, error) {
+	if !controllerutil.ContainsFinalizer(obj, replicationRoleFinalizer) {
+		return ctrl.Result{}, nil
+	}
+
+	if err := iteration.Finalize(obj); err != nil {
+		requeueAfter, statusErr := r.setFailedStatus(ctx, obj, err)
+		return ctrl.Result{RequeueAfter: requeueAfter}, statusErr
 	}
 
-	return ctrl.Result{}, nil
+	controllerutil.RemoveFinalizer(obj, replicationRoleFinalizer)
+	return ctrl.Result{}, r.Update(ctx, obj)
 }
 
+// setFailedStatus records the failure and returns delay of the next attempt
+// given by the retry policy of the failure's reason
 func (r *LogicalReplicationReconciler) setFailedStatus(ctx context.Context,
-	obj *replicationv1alpha1.LogicalReplication, err error) error {
+	obj *replicationv1alpha1.LogicalReplication, err error) (time.Duration, error) {
 	if err == nil {
-		return nil
+		return 0, nil
 	}
 
-	var reason string
+	var reason, hint string
 	replerr, ok := err.(ReplicationError)
 	if ok {
 		reason = string(replerr.Reason)
+		hint = replerr.Hint
 	}
 
-	obj.Status.ReplicationStatus = replicationv1alpha1.ReplicationStatus{
+	patch := client.MergeFrom(obj.DeepCopy())
+	status := replicationv1alpha1.ReplicationStatus{
 		Phase:   replicationv1alpha1.ReplicationPhaseFailed,
 		Message: err.Error(),
 		Reason:  reason,
+		Hint:    hint,
+	}
+
+	attempts := int32(1)
+	if obj.Status.Retry != nil && obj.Status.ReplicationStatus.Phase == status.Phase &&
+		obj.Status.ReplicationStatus.Reason == reason {
+		attempts = obj.Status.Retry.Attempts + 1
+	}
+	delay := retryPolicyForError(err).delay(attempts)
+	obj.Status.Retry = &replicationv1alpha1.RetryStatus{Attempts: attempts}
+	if delay > 0 {
+		next := metav1.NewTime(time.Now().Add(delay))
+		obj.Status.Retry.NextRetryTime = &next
+	}
+
+	// repeated failure is reported only once
+	if obj.Status.ReplicationStatus != status {
+		if reason == "" {
+			reason = ReconcileErrorEvent
+		}
+		message := err.Error()
+		if hint != "" {
+			message = fmt.Sprintf("%s, hint: %s", message, hint)
+		}
+		r.event(obj, corev1.EventTypeWarning, reason, message)
+	}
+	obj.Status.ReplicationStatus = status
+
+	return delay, r.Status().Patch(ctx, obj, patch)
+}
+
+func (r *LogicalReplicationReconciler) event(obj *replicationv1alpha1.LogicalReplication,
+	eventtype, reason, message string) {
+	if r.Recorder != nil {
+		r.Recorder.Event(obj, eventtype, reason, message)
+	}
+}
+
+// setReplicatingStatus records the reconciled state and returns delay of the next check
+// of a degraded subscription, zero when the subscription isn't degraded
+func (r *LogicalReplicationReconciler) setReplicatingStatus(ctx context.Context,
+	obj *replicationv1alpha1.LogicalReplication, iteration *LogicalReplicationIteration) (time.Duration, error) {
+	patch := client.MergeFrom(obj.DeepCopy())
+	obj.Status.Sequences = iteration.sequenceStatus
+	obj.Status.RetainedVersions = iteration.retainedVersions
+	obj.Status.SubscriptionErrors = iteration.subscriptionErrors
+
+	// consecutive checks of a degraded subscription are counted as retries
+	var delay time.Duration
+	attempts := int32(1)
+	if obj.Status.Retry != nil && obj.Status.ReplicationStatus.Phase != replicationv1alpha1.ReplicationPhaseFailed {
+		attempts = obj.Status.Retry.Attempts + 1
+	}
+	obj.Status.Retry = nil
+	if iteration.degraded() {
+		delay = degradedRetryPolicy.delay(attempts)
+		next := metav1.NewTime(time.Now().Add(delay))
+		obj.Status.Retry = &replicationv1alpha1.RetryStatus{Attempts: attempts, NextRetryTime: &next}
+	}
+	// the role is kept in status until it's dropped with the LogicalReplication
+	if iteration.replicationRole != nil {
+		obj.Status.ReplicationRole = iteration.replicationRole
+	}
+	now := metav1.Now()
+	obj.Status.Drift = replicationv1alpha1.DriftStatus{
+		LastResyncTime: &now,
+		Corrections:    iteration.corrections,
+	}
+	if iteration.health != nil {
+		previous := meta.FindStatusCondition(obj.Status.Conditions, replicationv1alpha1.ConditionDegraded)
+		if meta.SetStatusCondition(&obj.Status.Conditions, *iteration.health) {
+			if iteration.degraded() {
+				r.event(obj, corev1.EventTypeWarning, DegradedEvent, iteration.health.Message)
+			} else if previous != nil && previous.Status == metav1.ConditionTrue {
+				r.event(obj, corev1.EventTypeNormal, RecoveredEvent, iteration.health.Message)
+			}
+		}
+	}
+
+	// reconciled values keep the old publication until the switch is completed
+	if iteration.switching {
+		obj.Status.ReplicationStatus = replicationv1alpha1.ReplicationStatus{
+			Phase: replicationv1alpha1.ReplicationPhaseSwitching,
+			Message: fmt.Sprintf("waiting for %d tables of publication %s to synchronize",
+				iteration.unsyncedTables, obj.Spec.Publication.Name),
+		}
+		return delay, r.Status().Patch(ctx, obj, patch)
+	}
+
+	obj.Status.ReplicationStatus = replicationv1alpha1.ReplicationStatus{
+		Phase: replicationv1alpha1.ReplicationPhaseReplicating,
 	}
+	obj.Status.ReconciledValues.PublicationName = obj.Spec.Publication.Name
+	obj.Status.ReconciledValues.SubscriptionName = iteration.subscriptionName(obj.Spec.Publication.Name)
+	obj.Status.ReconciledValues.Tables = iteration.tables
 
-	patch := client.MergeFrom(obj)
-	return r.Status().Patch(ctx, obj, patch)
+	return delay, r.Status().Patch(ctx, obj, patch)
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *LogicalReplicationReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&replicationv1alpha1.LogicalReplication{}).
-		Complete(r)
+	managed := ctrl.NewControllerManagedBy(mgr).
+		For(&replicationv1alpha1.LogicalReplication{}, builder.WithPredicates(specChangedPredicate)).
+		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(r.replicationsForSecret))
+	if r.Breaker != nil {
+		managed = managed.WatchesRawSource(source.Channel(r.Breaker.Events(), &handler.EnqueueRequestForObject{}))
+	}
+	return managed.Complete(r)
+}
+
+// status updates written by the reconciliation don't trigger another one,
+// the retry backoff and resync interval schedule it instead
+var specChangedPredicate = predicate.Or(predicate.GenerationChangedPredicate{}, predicate.AnnotationChangedPredicate{})
+
+// replicationsForSecret reconciles replications using the changed Secret,
+// replications failing on their credentials wait for it
+func (r *LogicalReplicationReconciler) replicationsForSecret(ctx context.Context, secret client.Object) []reconcile.Request {
+	var list replicationv1alpha1.LogicalReplicationList
+	if err := r.List(ctx, &list, client.InNamespace(secret.GetNamespace())); err != nil {
+		log.FromContext(ctx).Error(err, "listing replications", "secret", secret.GetName())
+		return nil
+	}
+
+	requests := make([]reconcile.Request, 0)
+	for _, lr := range list.Items {
+		if slices.Contains(secretNames(&lr), secret.GetName()) {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{Namespace: lr.Namespace, Name: lr.Name},
+			})
+		}
+	}
+	return requests
+}
+
+// secretNames lists Secrets the replication reads credentials from
+func secretNames(lr *replicationv1alpha1.LogicalReplication) []string {
+	names := []string{lr.Spec.Publication.SecretName, lr.Spec.Subscription.SecretName}
+	if lr.Spec.Publication.ReplicationRole != nil {
+		names = append(names, replicationRoleSecretName(lr))
+	}
+	return names
 }
 
+const (
+	defaultSequenceSyncInterval  = 5 * time.Minute
+	partitionMaintenanceInterval = time.Hour
+	switchPollInterval           = 30 * time.Second
+	// resync is delayed by up to 10% so that replications don't reconcile at once
+	resyncJitter = 0.1
+	// corrections kept in the drift summary
+	maxDriftCorrections = 50
+	// a crashing apply worker is often found running with unchanged error counters,
+	// the subscription stays degraded until the worker keeps running this long
+	degradedHoldInterval = 2 * time.Minute
+)
+
 type LogicalReplicationIteration struct {
 	Client   client.Client
-	ctx      context.Context
-	Request  ctrl.Request
-	log      logr.Logger
-	obj      *replicationv1alpha1.LogicalReplication
-	pubCreds replication.DatabaseCredentials
-	pubDB    *sql.DB
-	subCreds replication.DatabaseCredentials
-	subDB    *sql.DB
+	Recorder record.EventRecorder
+	// shared connection pools, connections are closed after the iteration without it
+	Connections *replication.ConnectionManager
+	Breaker     *HostBreaker
+	ctx         context.Context
+	Request     ctrl.Request
+	log         logr.Logger
+	obj         *replicationv1alpha1.LogicalReplication
+	pubCreds    replication.DatabaseCredentials
+	pubDB       *sql.DB
+	// catalog changes on the publisher run as the admin user
+	pubAdminDB *sql.DB
+	// credentials the subscription connects to the publisher with
+	replCreds replication.DatabaseCredentials
+	// dedicated role of the subscription, nil without its spec
+	replicationRole *replicationv1alpha1.ReplicationRoleStatus
+	// previous role replaced by a role of a different name
+	retiredRole string
+	subCreds    replication.DatabaseCredentials
+	subDB       *sql.DB
+	// catalog changes on the subscriber run as the admin user
+	subAdminDB *sql.DB
+	// shared pools released after the iteration
+	acquired []*sql.DB
+	// hosts whose connection failed in this iteration
+	failedHosts map[string]bool
+	// subscriber's statements run in the transaction of the tables step,
+	// on subDB and subAdminDB otherwise
+	sub      replication.Querier
+	subAdmin replication.Querier
+	tables   []replication.PgTable
+	// the subscription has to be refreshed to replicate new tables
+	refreshSubscription bool
+	sequences           []replication.PgSequence
+	sequenceStatus      []replicationv1alpha1.SequenceStatus
+	// blue/green switch to the spec's publication is in progress
+	switching      bool
+	unsyncedTables int
+	// previous publications kept on the subscriber
+	retainedVersions []replicationv1alpha1.RetainedVersion
+	// subscription was created or altered, its workers are starting
+	subscriptionChanged bool
+	// reconciled name of the spec publication's subscription, it differs when the prefix changed
+	previousSubscription string
+	subscriptionErrors   replicationv1alpha1.SubscriptionErrors
+	// Degraded condition, nil when the subscription's health wasn't checked
+	health *metav1.Condition
+	// changes made on the subscriber
+	corrections []string
+	// changes made in the subscriber's transaction, reported once it commits
+	inTransaction bool
+	pendingEvents []pendingEvent
+}
+
+// pendingEvent is a change made in the subscriber's transaction
+type pendingEvent struct {
+	reason  string
+	message string
 }
 
 func (i *LogicalReplicationIteration) Iterate(lr *replicationv1alpha1.LogicalReplication) error {
 	i.log = log.FromContext(i.ctx)
 	i.obj = lr
+	i.retainedVersions = slices.Clone(lr.Status.RetainedVersions)
 
 	if err := i.readCredentails(); err != nil {
 		return err
 	}
 
+	defer i.closeDBs()
 	if err := i.connectDBs(); err != nil {
 		return err
 	}
+	defer i.collectMetrics()
 
 	if err := i.checkPublication(); err != nil {
 		return err
 	}
 
-	if i.publicationChanged() {
-		if err := i.renameTables(); err != nil {
+	i.switching = i.publicationChanged() && i.obj.Status.ReconciledValues.PublicationName != "" &&
+		i.obj.Spec.Publication.SwitchStrategy == replicationv1alpha1.PublicationSwitchBlueGreen
+	if !i.publicationChanged() {
+		i.previousSubscription = i.obj.Status.ReconciledValues.SubscriptionName
+	}
+
+	tables, err := i.publicationTables()
+	if err != nil {
+		return err
+	}
+	i.tables = tables
+
+	if i.publicationChanged() && !i.switching {
+		if err := i.renameTables(i.obj.Status.ReconciledValues.Tables); err != nil {
 			return err
 		}
 
 		if err := i.disableOldSubscription(); err != nil {
 			return err
 		}
+
+		if err := i.retireRenamedVersion(); err != nil {
+			return err
+		}
 	}
 
-	tables, err := i.publicationTables()
-	if err != nil {
-		return err
+	// shared tables are renamed before a rolled back version restores its tables
+	if i.switching {
+		if err = i.renameSharedTables(); err != nil {
+			return err
+		}
 	}
-	for _, table := range tables {
 
-		if err = i.checkSubscriptionSchema(table); err != nil {
+	if i.publicationChanged() {
+		if err := i.restoreVersion(); err != nil {
 			return err
 		}
+	}
+
+	details, err := i.checkSubscriptionTables(tables)
+	if err != nil {
+		return err
+	}
+
+	if err := i.checkReplicationRole(); err != nil {
+		return err
+	}
+
+	// the subscription can't be created in a transaction,
+	// it's created once the tables are committed
+	if err := i.checkSubscription(); err != nil {
+		return err
+	}
+
+	if err := i.dropRetiredRole(); err != nil {
+		return err
+	}
+
+	if err := i.checkSubscriptionHealth(); err != nil {
+		return err
+	}
 
-		if err = i.checkSubscriptionTable(table); err != nil {
+	if i.switching {
+		if err := i.switchPublication(details); err != nil {
 			return err
 		}
+	}
 
-		if err = i.checkSubscriptionView(table); err != nil {
+	if i.obj.Spec.Subscription.Sequences.Sync {
+		if err := i.syncSequences(); err != nil {
 			return err
 		}
 	}
 
-	if err := i.checkSubscription(); err != nil {
+	if err := i.dropExpiredVersions(); err != nil {
 		return err
 	}
 
 	return nil
 }
 
+// checkSubscriptionTables creates the tables with their dependencies, constraints
+// and views in a single transaction, so a failure doesn't leave them half-created
+func (i *LogicalReplicationIteration) checkSubscriptionTables(tables []replication.PgTable) ([]replication.PgTableDetail, error) {
+	published, err := i.readPublishedTables(tables)
+	if err != nil {
+		return nil, err
+	}
+
+	details := make([]replication.PgTableDetail, 0, len(tables))
+	err = replication.InTransaction(i.ctx, i.subAdminDB, func(tx replication.Querier) error {
+		i.sub, i.subAdmin, i.inTransaction = tx, tx, true
+		defer func() { i.sub, i.subAdmin, i.inTransaction = i.subDB, i.subAdminDB, false }()
+
+		for _, table := range published {
+			if err := i.checkSubscriptionSchema(table.detail.PgTable); err != nil {
+				return err
+			}
+
+			if err := i.checkSubscriptionDependencies(table); err != nil {
+				return err
+			}
+
+			if err := i.checkSubscriptionSequences(table); err != nil {
+				return err
+			}
+
+			detail, err := i.checkSubscriptionTable(table)
+			if err != nil {
+				return err
+			}
+			details = append(details, detail)
+		}
+
+		// foreign keys can reference any of the published tables,
+		// they are created once all tables exist
+		for _, detail := range details {
+			if err := i.checkSubscriptionConstraints(detail, true); err != nil {
+				return err
+			}
+		}
+
+		for _, detail := range details {
+			if err := i.checkSubscriptionTableDetail(detail); err != nil {
+				return err
+			}
+
+			// views are swapped once the new publication is synchronized
+			if i.switching {
+				continue
+			}
+
+			if err := i.checkSubscriptionView(detail); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	// changes rolled back with the transaction aren't reported
+	pending := i.pendingEvents
+	i.pendingEvents = nil
+	if err == nil {
+		for _, event := range pending {
+			i.recordEvent(event.reason, event.message)
+		}
+	}
+	if _, ok := err.(ReplicationError); err != nil && !ok {
+		i.log.Error(err, "committing subscription tables")
+		err = NewReplicationError(SubscriptionTablesError, err)
+	}
+	return details, err
+}
+
+// requeue to correct drift, refresh metrics, synchronize sequences, roll range partitions and rotate passwords
+// periodically, zero resync interval disables the periodic resync
+func (i *LogicalReplicationIteration) requeueAfter(resyncInterval time.Duration) time.Duration {
+	after := resyncInterval
+	if spec := i.obj.Spec.ResyncInterval; spec != nil {
+		after = spec.Duration
+	}
+	requeue := func(d time.Duration) {
+		if d > 0 && (after <= 0 || d < after) {
+			after = d
+		}
+	}
+
+	if spec := i.obj.Spec.Subscription.Sequences; spec.Sync {
+		if spec.SyncInterval != nil && spec.SyncInterval.Duration > 0 {
+			requeue(spec.SyncInterval.Duration)
+		} else {
+			requeue(defaultSequenceSyncInterval)
+		}
+	}
+
+	for _, table := range i.obj.Spec.Subscription.Tables {
+		if table.Partitioning != nil && table.Partitioning.Strategy == replicationv1alpha1.PartitionStrategyRange {
+			requeue(partitionMaintenanceInterval)
+		}
+	}
+	if i.switching {
+		requeue(switchPollInterval)
+	}
+	requeue(i.rotationDue())
+	if retention := i.obj.Spec.Subscription.Retention; retention != nil && retention.DropAfter != nil {
+		for _, version := range i.retainedVersions {
+			requeue(time.Until(version.RetiredAt.Add(retention.DropAfter.Duration)))
+		}
+	}
+	if after <= 0 {
+		return 0
+	}
+	return wait.Jitter(after, resyncJitter)
+}
+
+// export replication lag and subscription health, failures are only logged
+// as they don't affect the replication, series of a missing subscription or slot are removed
+// while read errors keep the last exported values
+func (i *LogicalReplicationIteration) collectMetrics() {
+	name := i.subscriptionName(i.obj.Spec.Publication.Name)
+	labels := prometheus.Labels{"namespace": i.obj.Namespace, "name": i.obj.Name, "subscription": name}
+	trackSubscription(labels)
+
+	subStats, err := replication.SubscriptionStatistics(i.ctx, i.sub, name)
+	if err == sql.ErrNoRows {
+		i.log.Info("missing subscription", "subscription", name)
+		deleteGroupMetrics(subscriptionMetrics, labels)
+	} else if err != nil {
+		i.log.Error(err, "reading statistics", "subscription", name)
+	} else {
+		setSubscriptionMetrics(labels, subStats)
+	}
+
+	slot, err := replication.SubscriptionSlotName(i.ctx, i.sub, name)
+	if err != nil && err != sql.ErrNoRows {
+		i.log.Error(err, "reading slot", "subscription", name)
+		return
+	}
+	if slot == "" {
+		slot = name
+	}
+
+	slotStats, err := replication.PublicationSlotStatistics(i.ctx, i.pubDB, slot)
+	if err == sql.ErrNoRows {
+		i.log.Info("missing replication slot", "slot", slot)
+		deleteGroupMetrics(slotMetrics, labels)
+	} else if err != nil {
+		i.log.Error(err, "reading statistics", "slot", slot)
+	} else {
+		setSlotMetrics(labels, slotStats)
+	}
+}
+
 func (i *LogicalReplicationIteration) readCredentails() error {
 	publishingDb, err := i.getCredentialsFromSecret(i.obj.Spec.Publication.SecretName)
 	if err != nil {
@@ -159,6 +617,7 @@ func (i *LogicalReplicationIteration) readCredentails() error {
 		return NewReplicationError(SecretError, err)
 	}
 	i.pubCreds = publishingDb
+	i.replCreds = publishingDb.Replication()
 
 	i.log.Info("publishing database", "databaseHost", publishingDb.Host, "databasePort", publishingDb.Port)
 
@@ -173,29 +632,99 @@ func (i *LogicalReplicationIteration) readCredentails() error {
 	return nil
 }
 
-func (i *LogicalReplicationIteration) connectDB(creds replication.DatabaseCredentials) (*sql.DB, error) {
-	db, err := replication.DBConnect(creds)
+func (i *LogicalReplicationIteration) connectDB(secretName string,
+	creds replication.DatabaseCredentials) (*sql.DB, error) {
+	host := hostKey(creds)
+	if !i.Breaker.Allow(host, i.Request.NamespacedName) {
+		replerr := NewReplicationError(HostUnavailable,
+			fmt.Errorf("database host %s is unavailable, waiting for it to recover", host))
+		replerr.Hint = "connections to the host failed repeatedly, check the database is running and reachable"
+		return nil, replerr
+	}
+
+	var db *sql.DB
+	var err error
+	if i.Connections != nil {
+		// pools are owned by the Secret so that a rotated Secret evicts the previous pool
+		db, err = i.Connections.Connect(i.ctx, i.Request.Namespace+"/"+secretName, creds)
+		if err == nil {
+			i.acquired = append(i.acquired, db)
+		}
+	} else {
+		// unshared sessions keep the database's timeouts
+		db, err = replication.DBConnect(i.ctx, creds, replication.Timeouts{})
+	}
 	if err != nil {
 		i.log.Error(err, fmt.Sprintf("connecting to %s db", creds.DatabaseName))
-		return nil, NewReplicationError(ConnectError, err)
+		replerr := NewReplicationError(ConnectError, err)
+		// the host answered when the database rejected the connection
+		if replerr.Reason != ConnectError && replerr.Reason != ConnectionLost {
+			i.Breaker.Success(host)
+		} else if !i.failedHosts[host] {
+			// connections to the same host fail together, the reconciliation counts as one failure
+			if i.failedHosts == nil {
+				i.failedHosts = make(map[string]bool)
+			}
+			i.failedHosts[host] = true
+			if i.Breaker.Failure(host) {
+				i.log.Info("database host is unavailable, its replications wait for it to recover", "host", host)
+			}
+		}
+		return nil, replerr
 	}
+	i.Breaker.Success(host)
 	i.log.Info(fmt.Sprintf("connected to %s database", creds.DatabaseName))
 	return db, nil
 }
 
 func (i *LogicalReplicationIteration) connectDBs() error {
 	var err error
-	i.pubDB, err = i.connectDB(i.pubCreds)
+	i.pubDB, err = i.connectDB(i.obj.Spec.Publication.SecretName, i.pubCreds)
+	if err != nil {
+		return err
+	}
+
+	// the admin account is needed only to manage the replication role
+	i.pubAdminDB = i.pubDB
+	if admin := i.pubCreds.Admin(); admin != i.pubCreds && i.obj.Spec.Publication.ReplicationRole != nil {
+		i.pubAdminDB, err = i.connectDB(i.obj.Spec.Publication.SecretName+"/admin", admin)
+		if err != nil {
+			return err
+		}
+	}
+
+	i.subDB, err = i.connectDB(i.obj.Spec.Subscription.SecretName, i.subCreds)
 	if err != nil {
 		return err
 	}
+	i.sub = i.subDB
 
-	i.subDB, err = i.connectDB(i.subCreds)
+	i.subAdminDB = i.subDB
+	if admin := i.subCreds.Admin(); admin != i.subCreds {
+		i.subAdminDB, err = i.connectDB(i.obj.Spec.Subscription.SecretName+"/admin", admin)
+	}
+	i.subAdmin = i.subAdminDB
 	return err
 }
 
+// closeDBs closes connections which aren't shared
+func (i *LogicalReplicationIteration) closeDBs() {
+	if i.Connections != nil {
+		for _, db := range i.acquired {
+			i.Connections.Release(db)
+		}
+		i.acquired = nil
+		return
+	}
+	for _, db := range []*sql.DB{i.pubDB, i.pubAdminDB, i.subDB, i.subAdminDB} {
+		if db != nil {
+			db.Close()
+		}
+	}
+}
+
 func (i *LogicalReplicationIteration) checkPublication() error {
-	err := replication.CheckPublication(i.pubDB, i.obj.Spec.Publication.Name)
+	err := replication.CheckPublication(i.ctx, i.pubDB, i.obj.Spec.Publication.Name)
 	if err != nil {
 		i.log.Error(err, "checking publication")
 		return NewReplicationError(PublicationError, err)
@@ -209,11 +738,17 @@ func (i *LogicalReplicationIteration) publicationChanged() bool {
 	return i.obj.Spec.Publication.Name != i.obj.Status.ReconciledValues.PublicationName
 }
 
-func (i *LogicalReplicationIteration) renameTables() error {
-	for _, table := range i.obj.Status.ReconciledValues.Tables {
+func (i *LogicalReplicationIteration) renameTables(tables []replication.PgTable) error {
+	naming, err := i.tableNaming()
+	if err != nil {
+		return err
+	}
+
+	oldSubscription := i.oldSubscriptionName()
+	for _, table := range tables {
 		// rename only if old table exist and renamed table does not
 
-		err := replication.CheckSubscriptionTable(i.subDB, table)
+		err = replication.CheckSubscriptionTable(i.ctx, i.sub, table)
 		if err == sql.ErrNoRows { // only report missing table and go to next
 			i.log.Error(err, "missing old subscription", "schema", table.Schema, "table", table.Name)
 			continue
@@ -222,149 +757,1245 @@ func (i *LogicalReplicationIteration) renameTables() error {
 			return NewReplicationError(SubscriptionTablesError, err)
 		}
 
-		newTable := replication.PgTable{
-			Schema: table.Schema,
-			Name:   table.Name + "_" + i.obj.Status.ReconciledValues.PublicationName,
+		newTable := naming.RenamedTable(table, i.obj.Status.ReconciledValues.PublicationName)
+		if newTable.Schema != table.Schema {
+			if err = i.checkSubscriptionSchema(newTable); err != nil {
+				return err
+			}
+		}
+
+		// both tables exist when the table of the new publication has been created after
+		// the rename, the renamed table is the one replicated by the old subscription
+		err = replication.CheckSubscriptionTable(i.ctx, i.sub, newTable)
+		if err == nil {
+			var renamed bool
+			renamed, err = replication.SubscriptionReplicatesTable(i.ctx, i.sub, oldSubscription, newTable)
+			if err != nil {
+				i.log.Error(err, "renaming old subscription", "schema", table.Schema, "table", table.Name)
+				return NewReplicationError(SubscriptionTablesError, err)
+			}
+			if renamed {
+				i.log.Info("old subscription already renamed", "schema", newTable.Schema, "table", newTable.Name)
+				continue
+			}
+			err = fmt.Errorf("table %s.%s can't be renamed, table %s.%s already exists",
+				table.Schema, table.Name, newTable.Schema, newTable.Name)
 		}
-		err = replication.CheckSubscriptionTable(i.subDB, newTable)
-		if err == nil { // table has been already renamed, go to next
-			continue
-		} else if err != sql.ErrNoRows {
+		if err != sql.ErrNoRows {
 			i.log.Error(err, "renaming old subscription", "schema", table.Schema, "table", table.Name)
 			return NewReplicationError(SubscriptionTablesError, err)
 		}
 
-		err = replication.RenameSubscriptionTable(i.subDB, table, newTable)
+		err = replication.RenameSubscriptionTable(i.ctx, i.subAdmin, table, newTable)
 		if err != nil {
 			i.log.Error(err, "renaming old subscription", "schema", table.Schema, "table", table.Name)
 			return NewReplicationError(SubscriptionTablesError, err)
 		}
+		i.event(RenamedTableEvent, "Renamed table %s.%s to %s.%s", table.Schema, table.Name, newTable.Schema, newTable.Name)
 	}
 	return nil
 }
 
-func (i *LogicalReplicationIteration) disableOldSubscription() error {
-	oldName := i.obj.Status.ReconciledValues.PublicationName
-	if oldName == "" {
-		return nil
+func (i *LogicalReplicationIteration) tableNaming() (replication.TableNaming, error) {
+	spec := i.obj.Spec.Subscription.Naming
+	naming := replication.TableNaming{
+		Strategy:      strings.ToLower(string(spec.Tables)),
+		ArchiveSchema: spec.ArchiveSchema,
 	}
-
-	if err := replication.CheckSubscription(i.subDB, oldName, ""); err != nil {
-		if err == sql.ErrNoRows {
-			i.log.Error(err, "old subscription does not exist", "subscription", oldName)
-			return nil
-		}
-		i.log.Error(err, "checking", "subscription", oldName)
-		return NewReplicationError(SubscriptionError, err)
+	if naming.Strategy == replication.SchemaNaming && naming.ArchiveSchema == "" {
+		err := fmt.Errorf("archive schema is required by %s naming", spec.Tables)
+		i.log.Error(err, "renaming old subscription")
+		return naming, NewReplicationError(SubscriptionTablesError, err)
 	}
+	return naming, nil
+}
 
-	if err := replication.DisableSubscription(i.subDB, oldName); err != nil {
-		i.log.Error(err, "disabling", "subscription", oldName)
-		return NewReplicationError(SubscriptionError, err)
+// subscription of the publication, its replication slot has the same name
+// unless the subscription has been renamed
+func (i *LogicalReplicationIteration) subscriptionName(pubname string) string {
+	return replication.TruncateIdentifier(i.obj.Spec.Subscription.Naming.SubscriptionPrefix + pubname)
+}
+
+// subscription of the reconciled publication, status written before subscription names
+// were recorded falls back to the current prefix
+func (i *LogicalReplicationIteration) oldSubscriptionName() string {
+	if name := i.obj.Status.ReconciledValues.SubscriptionName; name != "" {
+		return name
 	}
-	i.log.Info("disabled", "subscription", oldName)
+	return i.subscriptionName(i.obj.Status.ReconciledValues.PublicationName)
+}
 
-	return nil
+// subscription of a retained version, it keeps the prefix it was created with
+func (i *LogicalReplicationIteration) versionSubscriptionName(version replicationv1alpha1.RetainedVersion) string {
+	if version.SubscriptionName != "" {
+		return version.SubscriptionName
+	}
+	return i.subscriptionName(version.PublicationName)
 }
 
-func (i *LogicalReplicationIteration) publicationTables() ([]replication.PgTable, error) {
-	tables, err := replication.PublicationTables(i.pubDB, i.obj.Spec.Publication.Name)
-	if err != nil {
-		i.log.Error(err, "checking publication tables")
-		return nil, NewReplicationError(PublicationTablesError, err)
+// keep the old publication's tables on the subscriber until they expire,
+// a publication used again replaces its previous entry
+func (i *LogicalReplicationIteration) retireVersion(tables, originalTables []replication.PgTable) {
+	oldName := i.obj.Status.ReconciledValues.PublicationName
+	if oldName == "" {
+		return
 	}
-	i.log.Info("checked publication tables")
 
-	return tables, nil
+	i.retainedVersions = slices.DeleteFunc(i.retainedVersions, func(v replicationv1alpha1.RetainedVersion) bool {
+		return v.PublicationName == oldName
+	})
+	i.retainedVersions = append(i.retainedVersions, replicationv1alpha1.RetainedVersion{
+		PublicationName:  oldName,
+		SubscriptionName: i.oldSubscriptionName(),
+		Tables:           tables,
+		OriginalTables:   originalTables,
+		RetiredAt:        metav1.Now(),
+	})
 }
 
-func (i *LogicalReplicationIteration) checkSubscriptionSchema(table replication.PgTable) error {
-	err := replication.CheckSubscriptionSchema(i.subDB, table.Schema)
+func (i *LogicalReplicationIteration) retireRenamedVersion() error {
+	naming, err := i.tableNaming()
 	if err != nil {
-		if err == sql.ErrNoRows {
-			err = replication.CreateSubscriptionSchema(i.subDB, table.Schema)
-			if err != nil {
-				i.log.Error(err, "creating subscription", "schema", table.Name)
-				return NewReplicationError(SubscriptionError, err)
-			}
-			i.log.Info("created subscription", "schema", table.Name)
-		} else {
-			i.log.Error(err, "checking subscription", "schema", table.Schema)
-			return NewReplicationError(SubscriptionSchemaError, err)
-		}
+		return err
 	}
 
-	i.log.Info("checked subscription", "schema", table.Schema)
+	tables := i.obj.Status.ReconciledValues.Tables
+	renamed := make([]replication.PgTable, 0, len(tables))
+	for _, table := range tables {
+		renamed = append(renamed, naming.RenamedTable(table, i.obj.Status.ReconciledValues.PublicationName))
+	}
+	i.retireVersion(renamed, tables)
 	return nil
 }
 
-func (i *LogicalReplicationIteration) checkSubscriptionTable(table replication.PgTable) error {
-	tableDetail, err := replication.PublicationTableDetail(i.pubDB, table)
-	if err != nil {
-		i.log.Error(err, "reading publication details", "schema", table.Schema, "table", table.Name)
-		return NewReplicationError(PublicationTablesError, err)
+// roll back to a previous publication kept on the subscriber, its renamed tables
+// get their names back and checkSubscription enables its subscription again
+func (i *LogicalReplicationIteration) restoreVersion() error {
+	name := i.obj.Spec.Publication.Name
+	idx := slices.IndexFunc(i.retainedVersions, func(v replicationv1alpha1.RetainedVersion) bool {
+		return v.PublicationName == name
+	})
+	if idx < 0 {
+		return nil
 	}
-	i.log.Info("read publication details", "schema", table.Schema, "table", table.Name)
 
-	err = replication.CheckSubscriptionTable(i.subDB, table)
-	if err == sql.ErrNoRows {
-		err = replication.CreateSubscriptionTable(i.subDB, tableDetail)
-		if err != nil {
-			i.log.Error(err, "creating subscription", "schema", table.Schema, "table", table.Name)
-			return NewReplicationError(SubscriptionTablesError, err)
+	i.previousSubscription = i.versionSubscriptionName(i.retainedVersions[idx])
+	if len(i.retainedVersions[idx].OriginalTables) > 0 {
+		if err := i.restoreTables(i.retainedVersions[idx]); err != nil {
+			return err
 		}
-	} else if err != nil {
-		i.log.Error(err, "reading subscription", "schema", table.Schema, "table", table.Name)
-		return NewReplicationError(SubscriptionTablesError, err)
 	}
 
-	err = replication.CheckSubscriptionTableDetail(i.subDB, tableDetail)
-	if err != nil {
-		i.log.Error(err, "reading subscription details", "schema", table.Schema, "table", table.Name)
-		return NewReplicationError(SubscriptionTablesError, err)
-	}
+	i.retainedVersions = slices.Delete(i.retainedVersions, idx, idx+1)
+	i.log.Info("rolled back", "publication", name)
+	i.event(RolledBackEvent, "Rolled back to publication %s", name)
+	return nil
+}
+
+func (i *LogicalReplicationIteration) restoreTables(version replicationv1alpha1.RetainedVersion) error {
+	for idx, table := range version.Tables {
+		original := version.OriginalTables[idx]
+		if table == original { // not shared with the newer publication, kept its name
+			continue
+		}
+
+		err := replication.CheckSubscriptionTable(i.ctx, i.sub, table)
+		if err == sql.ErrNoRows { // table has been already restored, go to next
+			continue
+		} else if err != nil {
+			i.log.Error(err, "restoring old subscription", "schema", table.Schema, "table", table.Name)
+			return NewReplicationError(SubscriptionTablesError, err)
+		}
+
+		err = replication.CheckSubscriptionTable(i.ctx, i.sub, original)
+		if err == nil {
+			err = fmt.Errorf("table %s.%s can't be restored, table %s.%s already exists",
+				table.Schema, table.Name, original.Schema, original.Name)
+		}
+		if err != sql.ErrNoRows {
+			i.log.Error(err, "restoring old subscription", "schema", table.Schema, "table", table.Name)
+			return NewReplicationError(SubscriptionTablesError, err)
+		}
+
+		if err = replication.RenameSubscriptionTable(i.ctx, i.subAdmin, table, original); err != nil {
+			i.log.Error(err, "restoring old subscription", "schema", table.Schema, "table", table.Name)
+			return NewReplicationError(SubscriptionTablesError, err)
+		}
+		i.log.Info("restored old subscription", "schema", original.Schema, "table", original.Name)
+		i.event(RestoredTableEvent, "Restored table %s.%s from %s.%s", original.Schema, original.Name, table.Schema, table.Name)
+	}
+	return nil
+}
+
+// drop tables and subscriptions of previous publications exceeding the retention,
+// retained versions are ordered from the oldest
+func (i *LogicalReplicationIteration) dropExpiredVersions() error {
+	retention := i.obj.Spec.Subscription.Retention
+	if retention == nil {
+		return nil
+	}
+
+	retained := make([]replicationv1alpha1.RetainedVersion, 0, len(i.retainedVersions))
+	for idx, version := range i.retainedVersions {
+		newer := len(i.retainedVersions) - idx - 1
+		expired := (retention.KeepVersions != nil && newer >= int(*retention.KeepVersions)) ||
+			(retention.DropAfter != nil && time.Since(version.RetiredAt.Time) >= retention.DropAfter.Duration)
+		if !expired {
+			retained = append(retained, version)
+			continue
+		}
+
+		if err := i.dropVersion(version); err != nil {
+			return err
+		}
+	}
+	i.retainedVersions = retained
+	return nil
+}
+
+func (i *LogicalReplicationIteration) dropVersion(version replicationv1alpha1.RetainedVersion) error {
+	// the subscription is live again when its publication is used by the spec
+	if version.PublicationName != i.obj.Spec.Publication.Name {
+		subname := i.versionSubscriptionName(version)
+		if err := replication.DropSubscription(i.ctx, i.subAdminDB, subname); err != nil {
+			i.log.Error(err, "dropping old", "subscription", subname)
+			return NewReplicationError(SubscriptionError, err)
+		}
+		i.log.Info("dropped old", "subscription", subname)
+		i.event(DroppedSubscriptionEvent, "Dropped subscription %s", subname)
+	}
+
+	for _, table := range version.Tables {
+		if err := replication.DropSubscriptionTable(i.ctx, i.subAdmin, table); err != nil {
+			i.log.Error(err, "dropping old subscription", "schema", table.Schema, "table", table.Name)
+			return NewReplicationError(SubscriptionTablesError, err)
+		}
+		i.log.Info("dropped old subscription", "schema", table.Schema, "table", table.Name)
+		i.event(DroppedTableEvent, "Dropped table %s.%s", table.Schema, table.Name)
+	}
+	return nil
+}
+
+func (i *LogicalReplicationIteration) disableOldSubscription() error {
+	if i.obj.Status.ReconciledValues.PublicationName == "" {
+		return nil
+	}
+	oldName := i.oldSubscriptionName()
+
+	if err := replication.CheckSubscription(i.ctx, i.subAdmin, oldName, ""); err != nil {
+		if err == sql.ErrNoRows {
+			i.log.Error(err, "old subscription does not exist", "subscription", oldName)
+			return nil
+		}
+		if err == replication.ErrWrongAttributes { // already disabled
+			return nil
+		}
+		i.log.Error(err, "checking", "subscription", oldName)
+		return NewReplicationError(SubscriptionError, err)
+	}
+
+	if err := replication.DisableSubscription(i.ctx, i.subAdmin, oldName); err != nil {
+		i.log.Error(err, "disabling", "subscription", oldName)
+		return NewReplicationError(SubscriptionError, err)
+	}
+	i.log.Info("disabled", "subscription", oldName)
+	i.event(DisabledSubscriptionEvent, "Disabled subscription %s", oldName)
+
+	return nil
+}
+
+// blue/green switch replicates the new publication in parallel with the old one,
+// tables published by both can't be replicated by both subscriptions, the old
+// subscription is disabled and its copies of the shared tables are renamed aside,
+// consumer views keep selecting from the renamed tables until the swap
+func (i *LogicalReplicationIteration) renameSharedTables() error {
+	shared := i.sharedTables()
+	if len(shared) == 0 {
+		return nil
+	}
+
+	if err := i.disableOldSubscription(); err != nil {
+		return err
+	}
+	return i.renameTables(shared)
+}
+
+// tables of the old publication published also by the new one
+func (i *LogicalReplicationIteration) sharedTables() []replication.PgTable {
+	shared := make([]replication.PgTable, 0)
+	for _, table := range i.obj.Status.ReconciledValues.Tables {
+		if slices.Contains(i.tables, table) {
+			shared = append(shared, table)
+		}
+	}
+	return shared
+}
+
+// tables of the old publication kept after the switch, the shared ones under their renamed names
+func (i *LogicalReplicationIteration) retireSwitchedVersion() error {
+	shared := i.sharedTables()
+	if len(shared) == 0 {
+		i.retireVersion(i.obj.Status.ReconciledValues.Tables, nil)
+		return nil
+	}
+
+	naming, err := i.tableNaming()
+	if err != nil {
+		return err
+	}
+
+	tables := i.obj.Status.ReconciledValues.Tables
+	retired := make([]replication.PgTable, 0, len(tables))
+	for _, table := range tables {
+		if slices.Contains(shared, table) {
+			table = naming.RenamedTable(table, i.obj.Status.ReconciledValues.PublicationName)
+		}
+		retired = append(retired, table)
+	}
+	i.retireVersion(retired, tables)
+	return nil
+}
+
+// swap consumer facing views to the new publication's tables once all of them
+// are synchronized, the old subscription is disabled after the swap unless
+// shared tables disabled it already
+func (i *LogicalReplicationIteration) switchPublication(details []replication.PgTableDetail) error {
+	name := i.subscriptionName(i.obj.Spec.Publication.Name)
+	states, err := replication.SubscriptionTableStates(i.ctx, i.sub, name)
+	if err != nil {
+		i.log.Error(err, "checking synchronization", "subscription", name)
+		return NewReplicationError(SubscriptionError, err)
+	}
+
+	i.unsyncedTables = 0
+	for _, state := range states {
+		if state != replication.SubscriptionRelReady {
+			i.unsyncedTables++
+		}
+	}
+	if len(states) == 0 { // synchronization hasn't started yet
+		i.unsyncedTables = len(i.tables)
+	}
+	if i.unsyncedTables > 0 {
+		i.log.Info("waiting for synchronization", "subscription", name, "tables", i.unsyncedTables)
+		return nil
+	}
+
+	views := make([]replication.PgView, 0)
+	sources := make([]replication.PgTableDetail, 0)
+	for _, detail := range details {
+		view := i.subscriptionView(detail.PgTable)
+		if view == nil {
+			continue
+		}
+		if err = i.checkSubscriptionSchema(view.PgTable); err != nil {
+			return err
+		}
+		views = append(views, *view)
+		sources = append(sources, detail)
+	}
+
+	if err = replication.CreateSubscriptionViews(i.ctx, i.subAdmin, views, sources); err != nil {
+		i.log.Error(err, "swapping subscription views", "subscription", name)
+		return NewReplicationError(SubscriptionViewError, err)
+	}
+	i.log.Info("swapped subscription views", "subscription", name, "views", len(views))
+	i.event(SwitchedPublicationEvent, "Switched %d views to publication %s", len(views), i.obj.Spec.Publication.Name)
+
+	if err = i.disableOldSubscription(); err != nil {
+		return err
+	}
+
+	if err = i.retireSwitchedVersion(); err != nil {
+		return err
+	}
+	i.switching = false
+	return nil
+}
+
+func (i *LogicalReplicationIteration) publicationTables() ([]replication.PgTable, error) {
+	tables, err := replication.PublicationTables(i.ctx, i.pubDB, i.obj.Spec.Publication.Name)
+	if err != nil {
+		i.log.Error(err, "checking publication tables")
+		return nil, NewReplicationError(PublicationTablesError, err)
+	}
+	i.log.Info("checked publication tables")
+
+	return tables, nil
+}
+
+func (i *LogicalReplicationIteration) checkSubscriptionSchema(table replication.PgTable) error {
+	err := replication.CheckSubscriptionSchema(i.ctx, i.sub, table.Schema)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			err = replication.CreateSubscriptionSchema(i.ctx, i.subAdmin, table.Schema)
+			if err != nil {
+				i.log.Error(err, "creating subscription", "schema", table.Name)
+				return NewReplicationError(SubscriptionError, err)
+			}
+			i.log.Info("created subscription", "schema", table.Name)
+			i.event(CreatedSchemaEvent, "Created schema %s", table.Schema)
+		} else {
+			i.log.Error(err, "checking subscription", "schema", table.Schema)
+			return NewReplicationError(SubscriptionSchemaError, err)
+		}
+	}
+
+	i.log.Info("checked subscription", "schema", table.Schema)
+	return nil
+}
+
+// create extensions and types used by the published columns
+func (i *LogicalReplicationIteration) checkSubscriptionDependencies(published publishedTable) error {
+	table, deps := published.detail.PgTable, published.dependencies
+	for _, ext := range deps.Extensions {
+		err := replication.CheckSubscriptionExtension(i.ctx, i.sub, ext.Name)
+		if err == sql.ErrNoRows {
+			if err = replication.CreateSubscriptionSchema(i.ctx, i.subAdmin, ext.Schema); err == nil {
+				err = replication.CreateSubscriptionExtension(i.ctx, i.subAdmin, ext)
+			}
+			if err != nil {
+				i.log.Error(err, "creating subscription", "extension", ext.Name)
+				return NewReplicationError(SubscriptionDependenciesError, err)
+			}
+			i.log.Info("created subscription", "extension", ext.Name)
+			i.event(CreatedExtensionEvent, "Created extension %s", ext.Name)
+		} else if err != nil {
+			i.log.Error(err, "checking subscription", "extension", ext.Name)
+			return NewReplicationError(SubscriptionDependenciesError, err)
+		}
+	}
+
+	for _, typ := range deps.Types {
+		err := replication.CheckSubscriptionType(i.ctx, i.sub, typ)
+		if err == sql.ErrNoRows {
+			if err = replication.CreateSubscriptionSchema(i.ctx, i.subAdmin, typ.Schema); err == nil {
+				err = replication.CreateSubscriptionType(i.ctx, i.subAdmin, typ)
+			}
+			if err != nil {
+				i.log.Error(err, "creating subscription", "schema", typ.Schema, "type", typ.Name)
+				return NewReplicationError(SubscriptionDependenciesError, err)
+			}
+			i.log.Info("created subscription", "schema", typ.Schema, "type", typ.Name)
+			i.event(CreatedTypeEvent, "Created type %s.%s", typ.Schema, typ.Name)
+			continue
+		} else if err != nil {
+			i.log.Error(err, "checking subscription", "schema", typ.Schema, "type", typ.Name)
+			return NewReplicationError(SubscriptionDependenciesError, err)
+		}
+
+		if typ.Kind == replication.EnumType {
+			added, err := replication.AddSubscriptionEnumLabels(i.ctx, i.subAdmin, typ)
+			if err != nil {
+				i.log.Error(err, "adding enum labels", "schema", typ.Schema, "type", typ.Name)
+				return NewReplicationError(SubscriptionDependenciesError, err)
+			}
+			if added > 0 {
+				i.log.Info("added enum labels", "schema", typ.Schema, "type", typ.Name, "labels", added)
+				i.event(AddedEnumLabelsEvent, "Added %d labels to type %s.%s", added, typ.Schema, typ.Name)
+			}
+		}
+	}
+
+	i.log.Info("checked subscription dependencies", "schema", table.Schema, "table", table.Name)
+	return nil
+}
+
+// create sequences used by defaults of the published columns,
+// sequences of identity columns are created together with the table
+func (i *LogicalReplicationIteration) checkSubscriptionSequences(table publishedTable) error {
+	for _, seq := range table.sequences {
+		// a sequence shared by several tables is listed once
+		if slices.ContainsFunc(i.sequences, func(s replication.PgSequence) bool {
+			return s.Schema == seq.Schema && s.Name == seq.Name
+		}) {
+			continue
+		}
+		i.sequences = append(i.sequences, seq)
+		if seq.Identity {
+			continue
+		}
+
+		_, err := replication.CheckSubscriptionSequence(i.ctx, i.sub, seq)
+		if err == sql.ErrNoRows {
+			if err = replication.CreateSubscriptionSchema(i.ctx, i.subAdmin, seq.Schema); err == nil {
+				err = replication.CreateSubscriptionSequence(i.ctx, i.subAdmin, seq)
+			}
+			if err != nil {
+				i.log.Error(err, "creating subscription", "schema", seq.Schema, "sequence", seq.Name)
+				return NewReplicationError(SubscriptionSequencesError, err)
+			}
+			i.log.Info("created subscription", "schema", seq.Schema, "sequence", seq.Name)
+			i.event(CreatedSequenceEvent, "Created sequence %s.%s", seq.Schema, seq.Name)
+		} else if err != nil && err != replication.ErrSequenceNotReadable {
+			i.log.Error(err, "checking subscription", "schema", seq.Schema, "sequence", seq.Name)
+			return NewReplicationError(SubscriptionSequencesError, err)
+		}
+	}
+	return nil
+}
+
+// copy last values of the publisher's sequences to the subscriber
+func (i *LogicalReplicationIteration) syncSequences() error {
+	status := make([]replicationv1alpha1.SequenceStatus, 0, len(i.sequences))
+	for _, seq := range i.sequences {
+		if !seq.Readable {
+			err := fmt.Errorf("%w %s.%s on the publisher", replication.ErrSequenceNotReadable, seq.Schema, seq.Name)
+			i.log.Error(err, "reading publication", "schema", seq.Schema, "sequence", seq.Name)
+			replerr := NewReplicationError(SubscriptionSequencesError, err)
+			replerr.Transient = false
+			replerr.Hint = "grant USAGE or SELECT on the sequence to the publisher's user"
+			return replerr
+		}
+		if !seq.LastValue.Valid { // sequence has not been used yet
+			continue
+		}
+
+		// the admin user created the sequence, the subscriber's user may not read it
+		lastValue, err := replication.CheckSubscriptionSequence(i.ctx, i.subAdmin, seq)
+		if err == replication.ErrSequenceNotReadable {
+			err = fmt.Errorf("%w %s.%s on the subscriber", err, seq.Schema, seq.Name)
+			i.log.Error(err, "checking subscription", "schema", seq.Schema, "sequence", seq.Name)
+			replerr := NewReplicationError(SubscriptionSequencesError, err)
+			replerr.Transient = false
+			replerr.Hint = "grant USAGE or SELECT on the sequence to the subscriber's admin user"
+			return replerr
+		}
+		if err != nil {
+			i.log.Error(err, "checking subscription", "schema", seq.Schema, "sequence", seq.Name)
+			return NewReplicationError(SubscriptionSequencesError, err)
+		}
+
+		lag := seq.LastValue.Int64
+		if lastValue.Valid {
+			lag -= lastValue.Int64
+		}
+		// never move the subscriber's sequence back, it could have been already used
+		if lag > 0 {
+			if err = replication.SyncSubscriptionSequence(i.ctx, i.subAdmin, seq); err != nil {
+				i.log.Error(err, "synchronizing subscription", "schema", seq.Schema, "sequence", seq.Name)
+				return NewReplicationError(SubscriptionSequencesError, err)
+			}
+		}
+
+		status = append(status, replicationv1alpha1.SequenceStatus{
+			Schema:    seq.Schema,
+			Name:      seq.Name,
+			LastValue: seq.LastValue.Int64,
+			Lag:       lag,
+		})
+	}
+	i.sequenceStatus = status
+
+	i.log.Info("synchronized sequences", "count", len(status))
+	return nil
+}
+
+func (i *LogicalReplicationIteration) constraintTypes() []replication.ConstraintType {
+	spec := i.obj.Spec.Subscription.Constraints
+	types := make([]replication.ConstraintType, 0, 4)
+	if spec.Check {
+		types = append(types, replication.CheckConstraint)
+	}
+	if spec.Unique {
+		types = append(types, replication.UniqueConstraint)
+	}
+	// foreign keys need the primary key of the referenced table
+	if spec.ForeignKeys.Enabled {
+		types = append(types, replication.PrimaryKeyConstraint, replication.ForeignKeyConstraint)
+	}
+	return types
+}
+
+func (i *LogicalReplicationIteration) publicationTableConstraints(tables []replication.PgTable,
+	tableDetail replication.PgTableDetail) ([]replication.PgConstraint, error) {
+	constraints, err := replication.PublicationTableConstraints(i.ctx, i.pubDB, tableDetail, i.constraintTypes())
+	if err != nil {
+		return nil, err
+	}
+
+	spec := i.obj.Spec.Subscription.Constraints
+	fkSpec := spec.ForeignKeys
+	result := make([]replication.PgConstraint, 0, len(constraints))
+	for _, con := range constraints {
+		if con.Type == replication.ForeignKeyConstraint {
+			// referenced table would not exist on the subscriber
+			if !slices.Contains(tables, con.Reference) {
+				i.log.Info("skipping foreign key to not published table",
+					"schema", tableDetail.Schema, "table", tableDetail.Name, "constraint", con.Name)
+				continue
+			}
+			// referenced columns would not be unique on the subscriber
+			if con.ReferencedKey == "" || (con.ReferencedKey == replication.UniqueConstraint && !spec.Unique) {
+				i.log.Info("skipping foreign key to not replicated key",
+					"schema", tableDetail.Schema, "table", tableDetail.Name, "constraint", con.Name)
+				continue
+			}
+			con.Definition = replication.ForeignKeyDefinition(con.Definition, fkSpec.NotValid, fkSpec.Deferrable)
+		}
+		result = append(result, con)
+	}
+	return result, nil
+}
+
+// strip default expressions the subscriber should not have,
+// generation expressions of generated columns are always kept
+func (i *LogicalReplicationIteration) applyColumnDefaultsPolicy(
+	published publishedTable) (replication.PgTableDetail, error) {
+	table := published.detail
+	policy := i.obj.Spec.Subscription.ColumnDefaults
+	if policy == "" || policy == replicationv1alpha1.ColumnDefaultsCopy {
+		return table, nil
+	}
+
+	table.Columns = slices.Clone(table.Columns)
+	for idx, col := range table.Columns {
+		if !col.Default.Valid || col.Generated != "" {
+			continue
+		}
+
+		if policy == replicationv1alpha1.ColumnDefaultsCopyIfResolvable {
+			missing, err := replication.CheckSubscriptionObjects(i.ctx, i.sub, published.defaultDependencies[col.Name])
+			if err != nil {
+				i.log.Error(err, "resolving default", "schema", table.Schema, "table", table.Name, "column", col.Name)
+				return table, NewReplicationError(SubscriptionTablesError, err)
+			}
+			if len(missing) == 0 {
+				continue
+			}
+			i.log.Info("stripping unresolvable default", "schema", table.Schema, "table", table.Name,
+				"column", col.Name, "missing", missing)
+		}
+
+		table.Columns[idx].Default = sql.NullString{}
+	}
+	return table, nil
+}
+
+// metadata of a published table, it's read before the subscriber's transaction is opened
+// so that the transaction doesn't hold its locks while waiting for the publisher
+type publishedTable struct {
+	detail       replication.PgTableDetail
+	dependencies replication.PgDependencies
+	sequences    []replication.PgSequence
+	// objects used by column defaults, read only by the CopyIfResolvable policy
+	defaultDependencies map[string][]replication.PgObject
+}
+
+func (i *LogicalReplicationIteration) readPublishedTables(tables []replication.PgTable) ([]publishedTable, error) {
+	published := make([]publishedTable, 0, len(tables))
+	for _, table := range tables {
+		deps, err := replication.PublicationTableDependencies(i.ctx, i.pubDB, table)
+		if err != nil {
+			i.log.Error(err, "reading publication dependencies", "schema", table.Schema, "table", table.Name)
+			return nil, NewReplicationError(PublicationTablesError, err)
+		}
+
+		sequences, err := replication.PublicationTableSequences(i.ctx, i.pubDB, table)
+		if err != nil {
+			i.log.Error(err, "reading publication sequences", "schema", table.Schema, "table", table.Name)
+			return nil, NewReplicationError(PublicationTablesError, err)
+		}
+
+		tableDetail, err := replication.PublicationTableDetail(i.ctx, i.pubDB, table)
+		if err != nil {
+			i.log.Error(err, "reading publication details", "schema", table.Schema, "table", table.Name)
+			return nil, NewReplicationError(PublicationTablesError, err)
+		}
+
+		var defaultDeps map[string][]replication.PgObject
+		if i.obj.Spec.Subscription.ColumnDefaults == replicationv1alpha1.ColumnDefaultsCopyIfResolvable {
+			defaultDeps, err = replication.PublicationDefaultDependencies(i.ctx, i.pubDB, table)
+			if err != nil {
+				i.log.Error(err, "reading default dependencies", "schema", table.Schema, "table", table.Name)
+				return nil, NewReplicationError(PublicationTablesError, err)
+			}
+		}
+
+		tableDetail.PartitionKey, tableDetail.Partitions, err = replication.PublicationTablePartitions(i.ctx,
+			i.pubDB, i.obj.Spec.Publication.Name, table)
+		if err != nil {
+			i.log.Error(err, "reading publication partitions", "schema", table.Schema, "table", table.Name)
+			return nil, NewReplicationError(PublicationTablesError, err)
+		}
+
+		tableDetail.Constraints, err = i.publicationTableConstraints(tables, tableDetail)
+		if err != nil {
+			i.log.Error(err, "reading publication constraints", "schema", table.Schema, "table", table.Name)
+			return nil, NewReplicationError(PublicationTablesError, err)
+		}
+		i.log.Info("read publication details", "schema", table.Schema, "table", table.Name)
+
+		published = append(published, publishedTable{
+			detail:              tableDetail,
+			dependencies:        deps,
+			sequences:           sequences,
+			defaultDependencies: defaultDeps,
+		})
+	}
+	return published, nil
+}
+
+func (i *LogicalReplicationIteration) checkSubscriptionTable(published publishedTable) (replication.PgTableDetail, error) {
+	table := published.detail.PgTable
+	tableDetail, err := i.applyColumnDefaultsPolicy(published)
+	if err != nil {
+		return tableDetail, err
+	}
+
+	layout := i.partitionLayout(table)
+	if layout != nil {
+		// changes of the publisher's partitions would be applied to tables named after them
+		if len(tableDetail.Partitions) > 0 {
+			err = fmt.Errorf("partitioning of %s.%s on the subscriber requires publishing via partition root",
+				table.Schema, table.Name)
+			i.log.Error(err, "checking subscription partitioning", "schema", table.Schema, "table", table.Name)
+			return tableDetail, NewReplicationError(SubscriptionTablesError, err)
+		}
+		if err = layout.Validate(); err != nil {
+			i.log.Error(err, "checking subscription partitioning", "schema", table.Schema, "table", table.Name)
+			return tableDetail, NewReplicationError(SubscriptionTablesError, err)
+		}
+		tableDetail.PartitionKey, err = layout.PartitionKey(i.ctx, i.sub)
+		if err != nil {
+			i.log.Error(err, "rendering subscription partition key", "schema", table.Schema, "table", table.Name)
+			return tableDetail, NewReplicationError(SubscriptionTablesError, err)
+		}
+		tableDetail.Partitions = layout.Partitions(table, time.Now())
+	}
+
+	err = replication.CheckSubscriptionTable(i.ctx, i.sub, table)
+	if err == sql.ErrNoRows {
+		err = replication.CreateSubscriptionTable(i.ctx, i.subAdmin, tableDetail)
+		if err != nil {
+			i.log.Error(err, "creating subscription", "schema", table.Schema, "table", table.Name)
+			return tableDetail, NewReplicationError(SubscriptionTablesError, err)
+		}
+		i.event(CreatedTableEvent, "Created table %s.%s", table.Schema, table.Name)
+		// an existing subscription doesn't replicate the table until it's refreshed
+		i.refreshSubscription = true
+	} else if err != nil {
+		i.log.Error(err, "reading subscription", "schema", table.Schema, "table", table.Name)
+		return tableDetail, NewReplicationError(SubscriptionTablesError, err)
+	} else if err = i.checkSubscriptionColumnDefaults(tableDetail); err != nil {
+		return tableDetail, err
+	}
+
+	if err = i.checkSubscriptionPartitions(tableDetail, layout); err != nil {
+		return tableDetail, err
+	}
+
+	if err = i.checkSubscriptionConstraints(tableDetail, false); err != nil {
+		return tableDetail, err
+	}
+
+	return tableDetail, nil
+}
+
+// align default expressions of the existing table with the column defaults policy
+func (i *LogicalReplicationIteration) checkSubscriptionColumnDefaults(table replication.PgTableDetail) error {
+	changed, err := replication.CheckSubscriptionColumnDefaults(i.ctx, i.sub, table)
+	if err != nil {
+		i.log.Error(err, "reading subscription defaults", "schema", table.Schema, "table", table.Name)
+		return NewReplicationError(SubscriptionTablesError, err)
+	}
+
+	for _, col := range changed {
+		err = replication.AlterSubscriptionColumnDefault(i.ctx, i.subAdmin, table.PgTable, col)
+		if err != nil {
+			i.log.Error(err, "altering subscription default",
+				"schema", table.Schema, "table", table.Name, "column", col.Name)
+			return NewReplicationError(SubscriptionTablesError, err)
+		}
+		i.log.Info("altered subscription default", "schema", table.Schema, "table", table.Name, "column", col.Name)
+		i.event(AlteredColumnDefaultEvent, "Altered default of column %s of table %s.%s",
+			col.Name, table.Schema, table.Name)
+	}
+	return nil
+}
+
+// partition layout of the subscriber's table configured in the spec
+// settings of the published table, nil when the table isn't customized
+func (i *LogicalReplicationIteration) tableSpec(table replication.PgTable) *replicationv1alpha1.TableSpec {
+	idx := slices.IndexFunc(i.obj.Spec.Subscription.Tables, func(t replicationv1alpha1.TableSpec) bool {
+		return t.Schema == table.Schema && t.Name == table.Name
+	})
+	if idx < 0 {
+		return nil
+	}
+	return &i.obj.Spec.Subscription.Tables[idx]
+}
+
+func (i *LogicalReplicationIteration) partitionLayout(table replication.PgTable) *replication.PartitionLayout {
+	tableSpec := i.tableSpec(table)
+	if tableSpec == nil || tableSpec.Partitioning == nil {
+		return nil
+	}
+	spec := tableSpec.Partitioning
+
+	layout := &replication.PartitionLayout{
+		Key:       spec.Key,
+		Interval:  strings.ToLower(string(spec.Interval)),
+		Premake:   int(spec.Premake),
+		Retention: int(spec.Retention),
+		Modulus:   int(spec.Modulus),
+	}
+	switch spec.Strategy {
+	case replicationv1alpha1.PartitionStrategyRange:
+		layout.Strategy = replication.RangePartitioning
+	case replicationv1alpha1.PartitionStrategyList:
+		layout.Strategy = replication.ListPartitioning
+	case replicationv1alpha1.PartitionStrategyHash:
+		layout.Strategy = replication.HashPartitioning
+	}
+	for _, list := range spec.Lists {
+		layout.Lists = append(layout.Lists, replication.ListPartition{Name: list.Name, Values: list.Values})
+	}
+	return layout
+}
+
+// create partitions added on the publisher, the subscription has to be refreshed
+// to start replicating them. Partitions of the subscriber's own layout are rolled
+// and their bounds are not compared as they are rendered by the operator.
+func (i *LogicalReplicationIteration) checkSubscriptionPartitions(table replication.PgTableDetail,
+	layout *replication.PartitionLayout) error {
+	missing, err := replication.CheckSubscriptionPartitions(i.ctx, i.sub, table, layout == nil)
+	if err != nil {
+		i.log.Error(err, "checking subscription partitions", "schema", table.Schema, "table", table.Name)
+		return NewReplicationError(SubscriptionTablesError, err)
+	}
+
+	for _, partition := range missing {
+		err = replication.CreateSubscriptionSchema(i.ctx, i.subAdmin, partition.Schema)
+		if err == nil {
+			err = replication.CreateSubscriptionPartition(i.ctx, i.subAdmin, partition)
+		}
+		if err != nil {
+			i.log.Error(err, "creating subscription partition",
+				"schema", partition.Schema, "table", partition.Name)
+			return NewReplicationError(SubscriptionTablesError, err)
+		}
+		i.log.Info("created subscription partition", "schema", partition.Schema, "table", partition.Name)
+		i.event(CreatedPartitionEvent, "Created partition %s.%s", partition.Schema, partition.Name)
+		i.refreshSubscription = i.refreshSubscription || layout == nil
+	}
+
+	if layout == nil {
+		return nil
+	}
+
+	existing, err := replication.SubscriptionTablePartitions(i.ctx, i.sub, table.PgTable)
+	if err != nil {
+		i.log.Error(err, "reading subscription partitions", "schema", table.Schema, "table", table.Name)
+		return NewReplicationError(SubscriptionTablesError, err)
+	}
+	for _, partition := range layout.ExpiredPartitions(table.PgTable, existing, time.Now()) {
+		if err = replication.DropSubscriptionPartition(i.ctx, i.subAdmin, partition); err != nil {
+			i.log.Error(err, "dropping expired subscription partition",
+				"schema", partition.Schema, "table", partition.Name)
+			return NewReplicationError(SubscriptionTablesError, err)
+		}
+		i.log.Info("dropped expired subscription partition", "schema", partition.Schema, "table", partition.Name)
+		i.event(DroppedPartitionEvent, "Dropped expired partition %s.%s", partition.Schema, partition.Name)
+	}
+	return nil
+}
+
+// create missing constraints, foreign keys are handled separately
+// from the other constraints
+func (i *LogicalReplicationIteration) checkSubscriptionConstraints(table replication.PgTableDetail,
+	foreignKeys bool) error {
+	expected := make([]replication.PgConstraint, 0, len(table.Constraints))
+	for _, con := range table.Constraints {
+		if (con.Type == replication.ForeignKeyConstraint) == foreignKeys {
+			expected = append(expected, con)
+		}
+	}
+
+	missing, err := replication.CheckSubscriptionConstraints(i.ctx, i.sub, table.PgTable, expected)
+	if err != nil {
+		i.log.Error(err, "checking subscription constraints", "schema", table.Schema, "table", table.Name)
+		return NewReplicationError(SubscriptionTablesError, err)
+	}
+
+	for _, con := range missing {
+		err = replication.CreateSubscriptionConstraint(i.ctx, i.subAdmin, table.PgTable, con)
+		if err != nil {
+			i.log.Error(err, "creating subscription constraint",
+				"schema", table.Schema, "table", table.Name, "constraint", con.Name)
+			return NewReplicationError(SubscriptionTablesError, err)
+		}
+		i.log.Info("created subscription constraint",
+			"schema", table.Schema, "table", table.Name, "constraint", con.Name)
+		i.event(CreatedConstraintEvent, "Created constraint %s on table %s.%s", con.Name, table.Schema, table.Name)
+	}
+	return nil
+}
+
+func (i *LogicalReplicationIteration) checkSubscriptionTableDetail(table replication.PgTableDetail) error {
+	err := replication.CheckSubscriptionTableDetail(i.ctx, i.sub, table)
+	if err != nil {
+		i.log.Error(err, "reading subscription details", "schema", table.Schema, "table", table.Name)
+		return NewReplicationError(SubscriptionTablesError, err)
+	}
 
 	i.log.Info("checking publication details", "schema", table.Schema, "table", table.Name)
 	return nil
 }
 
-func (i *LogicalReplicationIteration) checkSubscriptionView(replication.PgTable) error {
+func (i *LogicalReplicationIteration) subscriptionView(table replication.PgTable) *replication.PgView {
+	tableSpec := i.tableSpec(table)
+	if tableSpec == nil || tableSpec.View == nil {
+		return nil
+	}
+	spec := tableSpec.View
+
+	view := &replication.PgView{
+		PgTable: replication.PgTable{Schema: spec.Schema, Name: spec.Name},
+		Source:  table,
+		Owner:   spec.Owner,
+		Filter:  spec.Filter,
+	}
+	if view.Name == "" {
+		view.Name = table.Name
+	}
+	for _, col := range spec.Columns {
+		view.Columns = append(view.Columns, replication.PgViewColumn{Name: col.Name, Alias: col.As, Cast: col.Cast})
+	}
+	return view
+}
+
+// create consumer facing view of the table, the view is recreated when its
+// definition, the replicated table's columns or the table it selects from change
+func (i *LogicalReplicationIteration) checkSubscriptionView(table replication.PgTableDetail) error {
+	view := i.subscriptionView(table.PgTable)
+	if view == nil {
+		return nil
+	}
+
+	if err := i.checkSubscriptionSchema(view.PgTable); err != nil {
+		return err
+	}
+
+	err := replication.CheckSubscriptionView(i.ctx, i.sub, *view, table)
+	switch err {
+	case nil:
+		i.log.Info("checked subscription", "view", view.Name, "schema", view.Schema)
+		return nil
+	case sql.ErrNoRows, replication.ErrWrongAttributes:
+	default:
+		i.log.Error(err, "checking subscription", "view", view.Name, "schema", view.Schema)
+		return NewReplicationError(SubscriptionViewError, err)
+	}
+
+	if err = replication.CreateSubscriptionView(i.ctx, i.subAdmin, *view, table); err != nil {
+		i.log.Error(err, "creating subscription", "view", view.Name, "schema", view.Schema)
+		return NewReplicationError(SubscriptionViewError, err)
+	}
+	i.log.Info("created subscription", "view", view.Name, "schema", view.Schema)
+	i.event(CreatedViewEvent, "Created view %s.%s", view.Schema, view.Name)
 	return nil
 }
 
+// subscriberConnection is the override of the publisher's connection made by the subscriber's server
+func (i *LogicalReplicationIteration) subscriberConnection() replication.SubscriberConnection {
+	spec := i.obj.Spec.Publication.SubscriberConnection
+	if spec == nil {
+		return replication.SubscriberConnection{}
+	}
+	conn := replication.SubscriberConnection{Host: spec.Host, Params: spec.Params}
+	if spec.Port > 0 {
+		conn.Port = strconv.Itoa(int(spec.Port))
+	}
+	return conn
+}
+
 func (i *LogicalReplicationIteration) checkSubscription() error {
-	connStr := replication.CredentialsToConnectionString(i.pubCreds)
-	name := i.obj.Spec.Publication.Name
+	name := i.subscriptionName(i.obj.Spec.Publication.Name)
+	connStr, err := replication.SubscriptionConnectionString(i.replCreds, i.subscriberConnection())
+	if err != nil {
+		i.log.Error(err, "building connection string", "subscription", name)
+		replerr := NewReplicationError(SubscriptionError, err)
+		replerr.Transient = false
+		replerr.Hint = "remove user, password, dbname, host, hostaddr and port from spec.publication.subscriberConnection.params"
+		return replerr
+	}
+
+	if err = i.renameSubscription(name); err != nil {
+		return err
+	}
 
-	err := replication.CheckSubscription(i.subDB, name, connStr)
+	err = replication.CheckSubscription(i.ctx, i.subAdmin, name, connStr)
 	if err != nil {
 		switch err {
 		case sql.ErrNoRows:
-			err = replication.CreateSubscription(i.subDB, name, connStr)
+			if err = replication.CheckCreateSubscriptionPrivilege(i.ctx, i.subAdmin); err != nil {
+				i.log.Error(err, "checking privileges", "subscription", name)
+				replerr := NewReplicationError(PermissionDenied, err)
+				if err == replication.ErrMissingPrivilege {
+					replerr.Transient = false
+					replerr.Hint = "grant pg_create_subscription and CREATE on the database to the subscriber's admin user " +
+						"(PostgreSQL 16+) or use a superuser"
+				}
+				return replerr
+			}
+			err = replication.CreateSubscription(i.ctx, i.subAdminDB, name, i.obj.Spec.Publication.Name, connStr)
 			if err != nil {
 				i.log.Error(err, "recreating", "subscription", name)
 				return NewReplicationError(SubscriptionError, err)
 			}
 			i.log.Info("created", "subscription", name)
+			i.event(CreatedSubscriptionEvent, "Created subscription %s", name)
+			i.subscriptionChanged = true
+
+			// the subscription replicates its tables once it's enabled and refreshed
+			if err = i.checkReplicationSlot(name); err != nil {
+				return err
+			}
+			if err = replication.EnableSubscription(i.ctx, i.subAdmin, name); err != nil {
+				i.log.Error(err, "enabling", "subscription", name)
+				return NewReplicationError(SubscriptionError, err)
+			}
+			i.refreshSubscription = true
 
 		case replication.ErrWrongAttributes:
 			i.log.Info("wrong attributes", "subscription", name)
-			err = replication.AlterSubscription(i.subDB, name, connStr)
+			if err = i.checkReplicationSlot(name); err != nil {
+				return err
+			}
+			err = replication.AlterSubscription(i.ctx, i.subAdmin, name, connStr)
 			if err != nil {
 				i.log.Error(err, "altering", "subscription", name)
 				return NewReplicationError(SubscriptionError, err)
 			}
+			i.event(AlteredSubscriptionEvent, "Altered subscription %s", name)
+			i.subscriptionChanged = true
 
 		default:
 			i.log.Error(err, "checking", "subscription", name)
 			return NewReplicationError(SubscriptionError, err)
 		}
 	}
+
+	if i.refreshSubscription {
+		err = replication.RefreshSubscription(i.ctx, i.subAdminDB, name)
+		if err != nil {
+			i.log.Error(err, "refreshing", "subscription", name)
+			return NewReplicationError(SubscriptionError, err)
+		}
+		i.log.Info("refreshed", "subscription", name)
+		i.event(RefreshedSubscriptionEvent, "Refreshed subscription %s", name)
+	}
 	i.log.Info("checked", "subscription", name)
 
 	return nil
 }
 
+// a changed subscription prefix renames the subscription, a new subscription
+// would copy the tables again, its replication slot keeps the previous name
+func (i *LogicalReplicationIteration) renameSubscription(name string) error {
+	oldName := i.previousSubscription
+	if oldName == "" || oldName == name {
+		return nil
+	}
+
+	err := replication.CheckSubscription(i.ctx, i.subAdmin, oldName, "")
+	if err == sql.ErrNoRows { // already renamed
+		return nil
+	} else if err != nil && err != replication.ErrWrongAttributes {
+		i.log.Error(err, "checking", "subscription", oldName)
+		return NewReplicationError(SubscriptionError, err)
+	}
+
+	if err = replication.RenameSubscription(i.ctx, i.subAdmin, oldName, name); err != nil {
+		i.log.Error(err, "renaming", "subscription", oldName)
+		return NewReplicationError(SubscriptionError, err)
+	}
+	i.log.Info("renamed", "subscription", oldName, "name", name)
+	i.event(RenamedSubscriptionEvent, "Renamed subscription %s to %s", oldName, name)
+	return nil
+}
+
+// create the subscription's slot on the publisher, the subscription is created
+// without connecting to the publisher which would create it
+func (i *LogicalReplicationIteration) checkReplicationSlot(subscription string) error {
+	name, err := replication.SubscriptionSlotName(i.ctx, i.subAdmin, subscription)
+	if err != nil {
+		i.log.Error(err, "checking slot", "subscription", subscription)
+		return NewReplicationError(SubscriptionError, err)
+	}
+	if name == "" {
+		return nil
+	}
+
+	err = replication.CheckReplicationSlot(i.ctx, i.pubAdminDB, name)
+	if err == sql.ErrNoRows {
+		if err = replication.CreateReplicationSlot(i.ctx, i.pubAdminDB, name); err != nil {
+			i.log.Error(err, "creating", "slot", name)
+			return NewReplicationError(SubscriptionError, err)
+		}
+		i.log.Info("created", "slot", name)
+		i.event(CreatedSlotEvent, "Created replication slot %s", name)
+	} else if err != nil {
+		i.log.Error(err, "checking", "slot", name)
+		return NewReplicationError(SubscriptionError, err)
+	}
+	return nil
+}
+
+// the subscription is degraded when its apply worker isn't running
+// or its workers' error counters grew since the last check,
+// it stays degraded until the worker has kept running for degradedHoldInterval
+func (i *LogicalReplicationIteration) checkSubscriptionHealth() error {
+	name := i.subscriptionName(i.obj.Spec.Publication.Name)
+	previous := i.obj.Status.SubscriptionErrors
+	i.subscriptionErrors = previous
+	if i.subscriptionChanged {
+		return nil
+	}
+
+	running, err := replication.SubscriptionWorkerRunning(i.ctx, i.sub, name)
+	if err != nil {
+		i.log.Error(err, "checking workers", "subscription", name)
+		return NewReplicationError(SubscriptionError, err)
+	}
+
+	stats, err := replication.SubscriptionStatistics(i.ctx, i.sub, name)
+	if err != nil {
+		i.log.Error(err, "checking subscription statistics", "subscription", name)
+		return NewReplicationError(SubscriptionError, err)
+	}
+	i.subscriptionErrors = replicationv1alpha1.SubscriptionErrors{
+		ApplyErrors:   stats.ApplyErrors,
+		SyncErrors:    stats.SyncErrors,
+		LastErrorTime: previous.LastErrorTime,
+	}
+	if !running || stats.ApplyErrors > previous.ApplyErrors || stats.SyncErrors > previous.SyncErrors {
+		now := metav1.Now()
+		i.subscriptionErrors.LastErrorTime = &now
+	}
+
+	i.health = &metav1.Condition{
+		Type:               replicationv1alpha1.ConditionDegraded,
+		Status:             metav1.ConditionFalse,
+		Reason:             replicationv1alpha1.ReasonHealthy,
+		Message:            fmt.Sprintf("apply worker of subscription %s is running", name),
+		ObservedGeneration: i.obj.Generation,
+	}
+	switch {
+	case !running:
+		i.health.Status = metav1.ConditionTrue
+		i.health.Reason = replicationv1alpha1.ReasonWorkerNotRunning
+		i.health.Message = fmt.Sprintf("apply worker of subscription %s is not running", name)
+	case stats.ApplyErrors > previous.ApplyErrors:
+		i.health.Status = metav1.ConditionTrue
+		i.health.Reason = replicationv1alpha1.ReasonApplyErrors
+		i.health.Message = fmt.Sprintf("apply errors of subscription %s grew from %d to %d",
+			name, previous.ApplyErrors, stats.ApplyErrors)
+	case stats.SyncErrors > previous.SyncErrors:
+		i.health.Status = metav1.ConditionTrue
+		i.health.Reason = replicationv1alpha1.ReasonSyncErrors
+		i.health.Message = fmt.Sprintf("table synchronization errors of subscription %s grew from %d to %d",
+			name, previous.SyncErrors, stats.SyncErrors)
+	case previous.LastErrorTime != nil && time.Since(previous.LastErrorTime.Time) < degradedHoldInterval:
+		// the previous condition is kept so that a held condition isn't reported again
+		i.health.Status = metav1.ConditionTrue
+		i.health.Reason = replicationv1alpha1.ReasonApplyErrors
+		i.health.Message = fmt.Sprintf("apply worker of subscription %s failed at %s",
+			name, previous.LastErrorTime.Format(time.RFC3339))
+		held := meta.FindStatusCondition(i.obj.Status.Conditions, replicationv1alpha1.ConditionDegraded)
+		if held != nil && held.Status == metav1.ConditionTrue {
+			i.health.Reason, i.health.Message = held.Reason, held.Message
+		}
+		i.log.Info("degraded until the worker keeps running", "subscription", name,
+			"lastErrorTime", previous.LastErrorTime.Time)
+		return nil
+	}
+
+	if i.degraded() {
+		if cause := i.subscriptionErrorCause(name, running, stats); cause != "" {
+			i.health.Message += ": " + cause
+		}
+		i.log.Info("degraded", "subscription", name, "reason", i.health.Reason, "message", i.health.Message)
+	} else {
+		i.log.Info("checked workers", "subscription", name)
+	}
+	return nil
+}
+
+// pg_subscription_rel states of tables that aren't ready yet
+var subscriptionRelStates = map[string]string{
+	"i": "initializing",
+	"d": "copying data",
+	"f": "finished copy",
+	"s": "synchronized",
+}
+
+// PostgreSQL keeps errors of the subscription's workers only in the server log,
+// the last error is described by the state of the subscription, its tables and its slot,
+// failures to read the state only leave the description out
+func (i *LogicalReplicationIteration) subscriptionErrorCause(name string, running bool,
+	stats replication.SubscriptionStats) string {
+	causes := make([]string, 0)
+
+	err := replication.CheckSubscription(i.ctx, i.subAdmin, name, "")
+	if err == replication.ErrWrongAttributes {
+		causes = append(causes, fmt.Sprintf("subscription %s is disabled", name))
+	} else if err != nil {
+		i.log.Error(err, "describing errors", "subscription", name)
+	}
+
+	states, err := replication.SubscriptionTableStates(i.ctx, i.subAdmin, name)
+	if err != nil {
+		i.log.Error(err, "describing errors", "subscription", name)
+	}
+	unsynced := make([]string, 0)
+	for table, state := range states {
+		if state != replication.SubscriptionRelReady {
+			unsynced = append(unsynced, fmt.Sprintf("%s.%s (%s)", table.Schema, table.Name, subscriptionRelStates[state]))
+		}
+	}
+	if len(unsynced) > 0 {
+		slices.Sort(unsynced)
+		causes = append(causes, "tables not synchronized: "+strings.Join(unsynced, ", "))
+	}
+
+	conflicting := make([]string, 0)
+	for conflictType, count := range stats.Conflicts {
+		if count > 0 {
+			conflicting = append(conflicting, fmt.Sprintf("%s %d", conflictType, count))
+		}
+	}
+	if len(conflicting) > 0 {
+		slices.Sort(conflicting)
+		causes = append(causes, "conflicts: "+strings.Join(conflicting, ", "))
+	}
+
+	slot, err := replication.SubscriptionSlotName(i.ctx, i.subAdmin, name)
+	if err != nil {
+		i.log.Error(err, "describing errors", "subscription", name)
+	}
+	if slot != "" {
+		slotStats, err := replication.PublicationSlotStatistics(i.ctx, i.pubDB, slot)
+		switch {
+		case err == sql.ErrNoRows:
+			causes = append(causes, fmt.Sprintf("replication slot %s does not exist on the publisher", slot))
+		case err != nil:
+			i.log.Error(err, "describing errors", "slot", slot)
+		case slotStats.WalStatus.String == "lost":
+			causes = append(causes, fmt.Sprintf("WAL required by replication slot %s has been removed", slot))
+		case slotStats.Active && !running:
+			causes = append(causes, fmt.Sprintf("replication slot %s is used by another connection", slot))
+		}
+	}
+
+	return strings.Join(causes, "; ")
+}
+
+func (i *LogicalReplicationIteration) degraded() bool {
+	return i.health != nil && i.health.Status == metav1.ConditionTrue
+}
+
 // Get secret with database credentials by name
 func (i *LogicalReplicationIteration) getCredentialsFromSecret(secretName string) (replication.DatabaseCredentials, error) {
 	var db replication.DatabaseCredentials
@@ -392,10 +2023,35 @@ func (i *LogicalReplicationIteration) getCredentialsFromSecret(secretName string
 	return db, err
 }
 
-func NewLogicalReplicationIteration(client client.Client, ctx context.Context, req ctrl.Request) *LogicalReplicationIteration {
+func NewLogicalReplicationIteration(client client.Client, recorder record.EventRecorder,
+	connections *replication.ConnectionManager, breaker *HostBreaker,
+	ctx context.Context, req ctrl.Request) *LogicalReplicationIteration {
 	return &LogicalReplicationIteration{
-		Client:  client,
-		ctx:     ctx,
-		Request: req,
+		Client:      client,
+		Recorder:    recorder,
+		Connections: connections,
+		Breaker:     breaker,
+		ctx:         ctx,
+		Request:     req,
+	}
+}
+
+// event reports a change made on the subscriber, the change is also listed in the drift summary,
+// changes made in the subscriber's transaction are reported once it commits
+func (i *LogicalReplicationIteration) event(reason, messageFmt string, args ...interface{}) {
+	message := fmt.Sprintf(messageFmt, args...)
+	if i.inTransaction {
+		i.pendingEvents = append(i.pendingEvents, pendingEvent{reason: reason, message: message})
+		return
+	}
+	i.recordEvent(reason, message)
+}
+
+func (i *LogicalReplicationIteration) recordEvent(reason, message string) {
+	if len(i.corrections) < maxDriftCorrections {
+		i.corrections = append(i.corrections, message)
+	}
+	if i.Recorder != nil {
+		i.Recorder.Event(i.obj, corev1.EventTypeNormal, reason, message)
 	}
 }
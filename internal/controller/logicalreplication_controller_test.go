@@ -3,12 +3,17 @@ package controller
 import (
 	"context"
 	"database/sql"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	corev1 "k8s.io/api/core/v1"
@@ -42,33 +47,61 @@ func runReconcile(ctx context.Context, namespace types.NamespacedName) (controll
 	return result, err
 }
 
+// runReconcileWithEvents reconciles with a fake recorder and returns the recorded
+// events formatted as "type reason message"
+func runReconcileWithEvents(ctx context.Context, namespace types.NamespacedName) (
+	controllerruntime.Result, []string, error) {
+	recorder := record.NewFakeRecorder(100)
+	controllerReconciler := &LogicalReplicationReconciler{
+		Client:   k8sClient,
+		Scheme:   k8sClient.Scheme(),
+		Recorder: recorder,
+	}
+
+	result, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+		NamespacedName: namespace,
+	})
+
+	events := make([]string, 0)
+	for {
+		select {
+		case event := <-recorder.Events:
+			events = append(events, event)
+		default:
+			return result, events, err
+		}
+	}
+}
+
 var (
 	expectedPeopleColumns = []replication.PgTableColumn{
 		{Name: "id", Nullable: false, Type: "uuid"},
-		{Name: "name", Nullable: true, Type: "character varying", CharacterMaximumLength: sql.NullInt32{Int32: 255, Valid: true}},
+		{Name: "name", Nullable: true, Type: "character varying(255)"},
 	}
 	expectedCitiesColumns = []replication.PgTableColumn{
 		{Name: "id", Nullable: false, Type: "uuid"},
-		{Name: "name", Nullable: true, Type: "character varying", CharacterMaximumLength: sql.NullInt32{Int32: 255, Valid: true}},
-		{Name: "zip", Nullable: true, Type: "character varying", CharacterMaximumLength: sql.NullInt32{Int32: 255, Valid: true}},
-		{Name: "country", Nullable: true, Type: "character varying", CharacterMaximumLength: sql.NullInt32{Int32: 255, Valid: true}},
+		{Name: "name", Nullable: true, Type: "character varying(255)"},
+		{Name: "zip", Nullable: true, Type: "character varying(255)"},
+		{Name: "country", Nullable: true, Type: "character varying(255)"},
 	}
 )
 
 func expectTableExists(db *sql.DB, schema, name string, expectedTableColumns []replication.PgTableColumn) {
 	GinkgoHelper()
 
-	rows, err := db.Query(`SELECT column_name,
-								  column_default,
-								  (is_nullable = 'YES'),
-								  data_type,
-								  character_maximum_length,
-								  numeric_precision,
-								  numeric_scale,
-								  datetime_precision
-							 FROM information_schema.columns c
-							WHERE table_schema = $1 AND table_name = $2
-							ORDER BY c.ordinal_position`,
+	rows, err := db.Query(`SELECT a.attname,
+								  pg_get_expr(d.adbin, d.adrelid),
+								  NOT a.attnotnull,
+								  format_type(a.atttypid, a.atttypmod),
+								  a.attidentity,
+								  a.attgenerated
+							 FROM pg_attribute a
+							 JOIN pg_class c ON a.attrelid = c.oid
+							 JOIN pg_namespace n ON c.relnamespace = n.oid
+							 LEFT JOIN pg_attrdef d ON a.attrelid = d.adrelid AND a.attnum = d.adnum
+							WHERE n.nspname = $1 AND c.relname = $2
+							  AND a.attnum > 0 AND NOT a.attisdropped
+							ORDER BY a.attnum`,
 		schema, name)
 	Expect(err).NotTo(HaveOccurred())
 	defer rows.Close()
@@ -81,10 +114,8 @@ func expectTableExists(db *sql.DB, schema, name string, expectedTableColumns []r
 			&col.Default,
 			&col.Nullable,
 			&col.Type,
-			&col.CharacterMaximumLength,
-			&col.NumericPrecision,
-			&col.NumericScale,
-			&col.DatetimePrecision,
+			&col.Identity,
+			&col.Generated,
 		)
 		Expect(err).NotTo(HaveOccurred())
 
@@ -94,6 +125,33 @@ func expectTableExists(db *sql.DB, schema, name string, expectedTableColumns []r
 	Expect(rows.Next()).To(BeFalse(), "extra column in %s.%s", schema, name)
 }
 
+func expectConstraintExists(db *sql.DB, schema, name, constraint string) {
+	GinkgoHelper()
+
+	row := db.QueryRow(`SELECT true
+						  FROM pg_constraint con
+						  JOIN pg_class c ON con.conrelid = c.oid
+						  JOIN pg_namespace n ON c.relnamespace = n.oid
+						 WHERE n.nspname = $1 AND c.relname = $2 AND con.conname = $3`,
+		schema, name, constraint)
+	var exists bool
+	Expect(row.Scan(&exists)).To(Succeed(), "missing constraint %s on %s.%s", constraint, schema, name)
+}
+
+func columnDefault(db *sql.DB, schema, name, column string) sql.NullString {
+	GinkgoHelper()
+
+	var def sql.NullString
+	Expect(db.QueryRow(`SELECT pg_get_expr(d.adbin, d.adrelid)
+						  FROM pg_attribute a
+						  JOIN pg_class c ON a.attrelid = c.oid
+						  JOIN pg_namespace n ON c.relnamespace = n.oid
+						  LEFT JOIN pg_attrdef d ON a.attrelid = d.adrelid AND a.attnum = d.adnum
+						 WHERE n.nspname = $1 AND c.relname = $2 AND a.attname = $3`,
+		schema, name, column).Scan(&def)).To(Succeed())
+	return def
+}
+
 func generateDbSecret(ctx context.Context, nn types.NamespacedName, database string) *corev1.Secret {
 	secret := &corev1.Secret{}
 
@@ -180,9 +238,9 @@ var _ = Describe("LogicalReplication Controller", func() {
 			}
 
 			By("connecting to test databases")
-			publisherDB, err = replication.DBConnect(generateDbCredentials("publisher"))
+			publisherDB, err = replication.DBConnect(ctx, generateDbCredentials("publisher"), replication.Timeouts{})
 			Expect(err).NotTo(HaveOccurred())
-			subscriberDB, err = replication.DBConnect(generateDbCredentials("subscriber"))
+			subscriberDB, err = replication.DBConnect(ctx, generateDbCredentials("subscriber"), replication.Timeouts{})
 			Expect(err).NotTo(HaveOccurred())
 		})
 
@@ -266,6 +324,653 @@ var _ = Describe("LogicalReplication Controller", func() {
 			expectTableExists(subscriberDB, "published_data", "cities", expectedCitiesColumns)
 		})
 
+		It("should replicate check and unique constraints", func() {
+			By("enabling constraints replication")
+			resource := &replicationv1alpha1.LogicalReplication{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			resource.Spec.Subscription.Constraints = replicationv1alpha1.ConstraintsSpec{
+				Check:  true,
+				Unique: true,
+			}
+			Expect(k8sClient.Update(ctx, resource)).To(Succeed())
+
+			By("remove schema")
+			_, err := subscriberDB.Exec("DROP SCHEMA published_data CASCADE")
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Reconciling the created resource")
+			_, err = runReconcile(ctx, typeNamespacedName)
+
+			Expect(err).NotTo(HaveOccurred())
+			expectConstraintExists(subscriberDB, "published_data", "cities", "cities_name_key")
+			expectConstraintExists(subscriberDB, "published_data", "cities", "cities_zip_check")
+
+			// primary keys are replicated only with foreign keys
+			var exists bool
+			Expect(subscriberDB.QueryRow(`SELECT EXISTS (SELECT 1 FROM pg_constraint
+														   WHERE conrelid = 'published_data.cities'::regclass
+															 AND contype = 'p')`).Scan(&exists)).To(Succeed())
+			Expect(exists).To(BeFalse())
+		})
+
+		It("should replicate foreign keys with the referenced primary keys", func() {
+			By("publishing a table referencing cities")
+			_, err := publisherDB.Exec(`CREATE TABLE published_data.addresses
+				(id UUID PRIMARY KEY, city_id UUID REFERENCES published_data.cities (id))`)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = publisherDB.Exec("ALTER PUBLICATION " + publicationName + " ADD TABLE published_data.addresses")
+			Expect(err).NotTo(HaveOccurred())
+			DeferCleanup(func() {
+				_, err := publisherDB.Exec("DROP TABLE published_data.addresses")
+				Expect(err).NotTo(HaveOccurred())
+				_, err = subscriberDB.Exec("DROP TABLE IF EXISTS published_data.addresses")
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			By("enabling foreign keys replication")
+			resource := &replicationv1alpha1.LogicalReplication{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			resource.Spec.Subscription.Constraints = replicationv1alpha1.ConstraintsSpec{
+				ForeignKeys: replicationv1alpha1.ForeignKeysSpec{Enabled: true, Deferrable: true},
+			}
+			Expect(k8sClient.Update(ctx, resource)).To(Succeed())
+
+			By("remove schema")
+			_, err = subscriberDB.Exec("DROP SCHEMA published_data CASCADE")
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Reconciling the created resource")
+			_, err = runReconcile(ctx, typeNamespacedName)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(resource.Status.ReplicationStatus.Phase).NotTo(Equal(replicationv1alpha1.ReplicationPhaseFailed))
+			expectConstraintExists(subscriberDB, "published_data", "cities", "cities_pkey")
+			expectConstraintExists(subscriberDB, "published_data", "addresses", "addresses_pkey")
+			expectConstraintExists(subscriberDB, "published_data", "addresses", "addresses_city_id_fkey")
+		})
+
+		It("should refresh the subscription for new published tables", func() {
+			By("publishing a new table")
+			_, err := publisherDB.Exec("CREATE TABLE published_data.countries (id UUID PRIMARY KEY, name VARCHAR(255))")
+			Expect(err).NotTo(HaveOccurred())
+			_, err = publisherDB.Exec("ALTER PUBLICATION " + publicationName + " ADD TABLE published_data.countries")
+			Expect(err).NotTo(HaveOccurred())
+			DeferCleanup(func() {
+				_, err := publisherDB.Exec("DROP TABLE published_data.countries")
+				Expect(err).NotTo(HaveOccurred())
+				_, err = subscriberDB.Exec("DROP TABLE IF EXISTS published_data.countries")
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			By("Reconciling the created resource")
+			_, err = runReconcile(ctx, typeNamespacedName)
+			Expect(err).NotTo(HaveOccurred())
+
+			expectTableExists(subscriberDB, "published_data", "countries", expectedPeopleColumns)
+			states, err := replication.SubscriptionTableStates(ctx, subscriberDB, publicationName)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(states).To(HaveKey(replication.PgTable{Schema: "published_data", Name: "countries"}))
+		})
+
+		It("should strip defaults of existing tables", func() {
+			By("adding a default on both databases")
+			for _, db := range []*sql.DB{publisherDB, subscriberDB} {
+				_, err := db.Exec("ALTER TABLE published_data.cities ALTER country SET DEFAULT 'USA'")
+				Expect(err).NotTo(HaveOccurred())
+			}
+			DeferCleanup(func() {
+				_, err := publisherDB.Exec("ALTER TABLE published_data.cities ALTER country DROP DEFAULT")
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			By("stripping defaults")
+			resource := &replicationv1alpha1.LogicalReplication{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			resource.Spec.Subscription.ColumnDefaults = replicationv1alpha1.ColumnDefaultsStrip
+			Expect(k8sClient.Update(ctx, resource)).To(Succeed())
+
+			By("Reconciling the created resource")
+			_, err := runReconcile(ctx, typeNamespacedName)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(resource.Status.ReplicationStatus.Phase).To(Equal(replicationv1alpha1.ReplicationPhaseReplicating))
+			Expect(columnDefault(subscriberDB, "published_data", "cities", "country").Valid).To(BeFalse())
+		})
+
+		It("should copy defaults once they are resolvable", func() {
+			By("adding a default calling a function of the publisher")
+			_, err := publisherDB.Exec(`CREATE FUNCTION published_data.default_country() RETURNS text
+				LANGUAGE sql AS $$ SELECT 'USA' $$`)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = publisherDB.Exec("ALTER TABLE published_data.cities ALTER country SET DEFAULT published_data.default_country()")
+			Expect(err).NotTo(HaveOccurred())
+			DeferCleanup(func() {
+				_, err := publisherDB.Exec("DROP FUNCTION published_data.default_country() CASCADE")
+				Expect(err).NotTo(HaveOccurred())
+				_, err = subscriberDB.Exec("DROP FUNCTION IF EXISTS published_data.default_country() CASCADE")
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			By("copying resolvable defaults")
+			resource := &replicationv1alpha1.LogicalReplication{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			resource.Spec.Subscription.ColumnDefaults = replicationv1alpha1.ColumnDefaultsCopyIfResolvable
+			Expect(k8sClient.Update(ctx, resource)).To(Succeed())
+
+			By("Reconciling without the function on the subscriber")
+			_, err = runReconcile(ctx, typeNamespacedName)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(resource.Status.ReplicationStatus.Phase).To(Equal(replicationv1alpha1.ReplicationPhaseReplicating))
+			Expect(columnDefault(subscriberDB, "published_data", "cities", "country").Valid).To(BeFalse())
+
+			By("Reconciling with the function on the subscriber")
+			_, err = subscriberDB.Exec(`CREATE FUNCTION published_data.default_country() RETURNS text
+				LANGUAGE sql AS $$ SELECT 'USA' $$`)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = runReconcile(ctx, typeNamespacedName)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(resource.Status.ReplicationStatus.Phase).To(Equal(replicationv1alpha1.ReplicationPhaseReplicating))
+			Expect(columnDefault(subscriberDB, "published_data", "cities", "country")).
+				To(Equal(columnDefault(publisherDB, "published_data", "cities", "country")))
+		})
+
+		It("should not leave tables behind when a step fails", func() {
+			By("adding a view with invalid filter")
+			resource := &replicationv1alpha1.LogicalReplication{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			resource.Spec.Subscription.Tables = []replicationv1alpha1.TableSpec{{
+				Schema: "published_data",
+				Name:   "cities",
+				View: &replicationv1alpha1.ViewSpec{
+					Schema: "published_data",
+					Name:   "cities_view",
+					Filter: "no_such_column > 0",
+				},
+			}}
+			Expect(k8sClient.Update(ctx, resource)).To(Succeed())
+
+			By("remove schema")
+			_, err := subscriberDB.Exec("DROP SCHEMA published_data CASCADE")
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Reconciling the created resource")
+			_, events, err := runReconcileWithEvents(ctx, typeNamespacedName)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(events).NotTo(ContainElement(ContainSubstring(CreatedSchemaEvent)))
+			Expect(events).NotTo(ContainElement(ContainSubstring(CreatedTableEvent)))
+
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(resource.Status.ReplicationStatus.Phase).To(Equal(replicationv1alpha1.ReplicationPhaseFailed))
+			var exists bool
+			Expect(subscriberDB.QueryRow("SELECT to_regclass('published_data.people') IS NOT NULL").Scan(&exists)).
+				To(Succeed())
+			Expect(exists).To(BeFalse())
+		})
+
+		It("should switch to a new publication in parallel", func() {
+			subscriberAdminDB, err := replication.DBConnect(ctx, generateDbCredentials("subscriber").Admin(),
+				replication.Timeouts{})
+			Expect(err).NotTo(HaveOccurred())
+			DeferCleanup(subscriberAdminDB.Close)
+
+			By("publishing a new table")
+			_, err = publisherDB.Exec("CREATE TABLE published_data.regions (id UUID PRIMARY KEY, name VARCHAR(255))")
+			Expect(err).NotTo(HaveOccurred())
+			_, err = publisherDB.Exec("INSERT INTO published_data.regions VALUES (gen_random_uuid(), 'Europe')")
+			Expect(err).NotTo(HaveOccurred())
+			_, err = publisherDB.Exec("CREATE PUBLICATION publication_v2 FOR TABLE published_data.regions")
+			Expect(err).NotTo(HaveOccurred())
+			DeferCleanup(func() {
+				_, err := subscriberAdminDB.Exec("DROP SUBSCRIPTION IF EXISTS publication_v2")
+				Expect(err).NotTo(HaveOccurred())
+				_, err = subscriberAdminDB.Exec("ALTER SUBSCRIPTION " + publicationName + " ENABLE")
+				Expect(err).NotTo(HaveOccurred())
+				_, err = subscriberDB.Exec("DROP TABLE IF EXISTS published_data.regions")
+				Expect(err).NotTo(HaveOccurred())
+				_, err = publisherDB.Exec("DROP PUBLICATION publication_v2")
+				Expect(err).NotTo(HaveOccurred())
+				_, err = publisherDB.Exec("DROP TABLE published_data.regions")
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			By("Reconciling the current publication")
+			_, err = runReconcile(ctx, typeNamespacedName)
+			Expect(err).NotTo(HaveOccurred())
+			resource := &replicationv1alpha1.LogicalReplication{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(resource.Status.ReconciledValues.PublicationName).To(Equal(publicationName))
+
+			By("switching to the new publication")
+			resource.Spec.Publication.Name = "publication_v2"
+			resource.Spec.Publication.SwitchStrategy = replicationv1alpha1.PublicationSwitchBlueGreen
+			Expect(k8sClient.Update(ctx, resource)).To(Succeed())
+
+			Eventually(func(g Gomega) {
+				_, err := runReconcile(ctx, typeNamespacedName)
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+				g.Expect(resource.Status.ReplicationStatus.Phase).To(Equal(replicationv1alpha1.ReplicationPhaseReplicating))
+			}, 30*time.Second, time.Second).Should(Succeed())
+
+			Expect(resource.Status.ReconciledValues.PublicationName).To(Equal("publication_v2"))
+			Expect(resource.Status.RetainedVersions).To(ContainElement(
+				HaveField("PublicationName", publicationName)))
+			var rows int
+			Expect(subscriberDB.QueryRow("SELECT count(*) FROM published_data.regions").Scan(&rows)).To(Succeed())
+			Expect(rows).To(Equal(1))
+		})
+
+		It("should switch to a new publication sharing tables in parallel", func() {
+			subscriberAdminDB, err := replication.DBConnect(ctx, generateDbCredentials("subscriber").Admin(),
+				replication.Timeouts{})
+			Expect(err).NotTo(HaveOccurred())
+			DeferCleanup(subscriberAdminDB.Close)
+
+			By("publishing a shared table")
+			_, err = publisherDB.Exec("CREATE PUBLICATION publication_v2 FOR TABLE published_data.cities (id, name, zip, country)")
+			Expect(err).NotTo(HaveOccurred())
+			DeferCleanup(func() {
+				_, err := subscriberAdminDB.Exec("DROP SUBSCRIPTION IF EXISTS publication_v2")
+				Expect(err).NotTo(HaveOccurred())
+				_, err = subscriberAdminDB.Exec("DROP TABLE published_data.cities")
+				Expect(err).NotTo(HaveOccurred())
+				_, err = subscriberAdminDB.Exec("ALTER TABLE published_data.cities_publication_v1 RENAME TO cities")
+				Expect(err).NotTo(HaveOccurred())
+				_, err = subscriberAdminDB.Exec("ALTER SUBSCRIPTION " + publicationName + " ENABLE")
+				Expect(err).NotTo(HaveOccurred())
+				_, err = publisherDB.Exec("DROP PUBLICATION publication_v2")
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			By("Reconciling the current publication")
+			_, err = runReconcile(ctx, typeNamespacedName)
+			Expect(err).NotTo(HaveOccurred())
+			resource := &replicationv1alpha1.LogicalReplication{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(resource.Status.ReconciledValues.PublicationName).To(Equal(publicationName))
+
+			By("switching to the new publication")
+			resource.Spec.Publication.Name = "publication_v2"
+			resource.Spec.Publication.SwitchStrategy = replicationv1alpha1.PublicationSwitchBlueGreen
+			Expect(k8sClient.Update(ctx, resource)).To(Succeed())
+
+			Eventually(func(g Gomega) {
+				_, err := runReconcile(ctx, typeNamespacedName)
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+				g.Expect(resource.Status.ReplicationStatus.Phase).To(Equal(replicationv1alpha1.ReplicationPhaseReplicating))
+			}, 30*time.Second, time.Second).Should(Succeed())
+
+			Expect(resource.Status.ReconciledValues.PublicationName).To(Equal("publication_v2"))
+			cities := replication.PgTable{Schema: "published_data", Name: "cities"}
+			renamed := replication.PgTable{Schema: "published_data", Name: "cities_publication_v1"}
+			Expect(resource.Status.RetainedVersions).To(ContainElement(SatisfyAll(
+				HaveField("PublicationName", publicationName),
+				HaveField("Tables", ContainElement(renamed)),
+				HaveField("OriginalTables", ContainElement(cities)))))
+			expectTableExists(subscriberDB, "published_data", "cities_publication_v1", expectedCitiesColumns)
+			var rows int
+			Expect(subscriberDB.QueryRow("SELECT count(*) FROM published_data.cities").Scan(&rows)).To(Succeed())
+			Expect(rows).To(Equal(3))
+		})
+
+		It("should rename the subscription when its prefix changes", func() {
+			subscriberAdminDB, err := replication.DBConnect(ctx, generateDbCredentials("subscriber").Admin(),
+				replication.Timeouts{})
+			Expect(err).NotTo(HaveOccurred())
+			DeferCleanup(subscriberAdminDB.Close)
+
+			By("Reconciling the current subscription")
+			_, err = runReconcile(ctx, typeNamespacedName)
+			Expect(err).NotTo(HaveOccurred())
+			resource := &replicationv1alpha1.LogicalReplication{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(resource.Status.ReconciledValues.SubscriptionName).To(Equal(publicationName))
+
+			By("changing the subscription prefix")
+			resource.Spec.Subscription.Naming.SubscriptionPrefix = "renamed_"
+			Expect(k8sClient.Update(ctx, resource)).To(Succeed())
+			DeferCleanup(func() {
+				_, err := subscriberAdminDB.Exec("DO $$ BEGIN " +
+					"IF EXISTS (SELECT FROM pg_subscription WHERE subname = 'renamed_" + publicationName + "') THEN " +
+					"ALTER SUBSCRIPTION renamed_" + publicationName + " RENAME TO " + publicationName + "; " +
+					"END IF; END $$")
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			_, err = runReconcile(ctx, typeNamespacedName)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(resource.Status.ReconciledValues.SubscriptionName).To(Equal("renamed_" + publicationName))
+
+			Expect(replication.CheckSubscription(ctx, subscriberAdminDB, publicationName, "")).
+				To(MatchError(sql.ErrNoRows))
+			Expect(replication.CheckSubscription(ctx, subscriberAdminDB, "renamed_"+publicationName, "")).To(Succeed())
+			slot, err := replication.SubscriptionSlotName(ctx, subscriberAdminDB, "renamed_"+publicationName)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(slot).To(Equal(publicationName))
+		})
+
+		// publication_v2 publishes a table of its own, the subscriber's renamed copies are dropped on cleanup
+		publishRegions := func() *sql.DB {
+			subscriberAdminDB, err := replication.DBConnect(ctx, generateDbCredentials("subscriber").Admin(),
+				replication.Timeouts{})
+			Expect(err).NotTo(HaveOccurred())
+			DeferCleanup(subscriberAdminDB.Close)
+
+			_, err = publisherDB.Exec("CREATE TABLE published_data.regions (id UUID PRIMARY KEY, name VARCHAR(255))")
+			Expect(err).NotTo(HaveOccurred())
+			_, err = publisherDB.Exec("CREATE PUBLICATION publication_v2 FOR TABLE published_data.regions")
+			Expect(err).NotTo(HaveOccurred())
+			DeferCleanup(func() {
+				_, err := subscriberAdminDB.Exec("DROP SUBSCRIPTION IF EXISTS publication_v2")
+				Expect(err).NotTo(HaveOccurred())
+				_, err = subscriberAdminDB.Exec("ALTER SUBSCRIPTION " + publicationName + " ENABLE")
+				Expect(err).NotTo(HaveOccurred())
+				_, err = subscriberDB.Exec("DROP TABLE IF EXISTS published_data.regions, published_data.regions_publication_v2")
+				Expect(err).NotTo(HaveOccurred())
+				_, err = publisherDB.Exec("DROP PUBLICATION publication_v2")
+				Expect(err).NotTo(HaveOccurred())
+				_, err = publisherDB.Exec("DROP TABLE published_data.regions")
+				Expect(err).NotTo(HaveOccurred())
+			})
+			return subscriberAdminDB
+		}
+
+		// switch the resource to the publication and reconcile it
+		switchPublication := func(name string,
+			retention *replicationv1alpha1.RetentionSpec) *replicationv1alpha1.LogicalReplication {
+			resource := &replicationv1alpha1.LogicalReplication{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			resource.Spec.Publication.Name = name
+			resource.Spec.Subscription.Retention = retention
+			Expect(k8sClient.Update(ctx, resource)).To(Succeed())
+
+			_, err := runReconcile(ctx, typeNamespacedName)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(resource.Status.ReconciledValues.PublicationName).To(Equal(name))
+			return resource
+		}
+
+		It("should roll back to a previous publication", func() {
+			subscriberAdminDB := publishRegions()
+
+			By("Reconciling the current publication")
+			_, err := runReconcile(ctx, typeNamespacedName)
+			Expect(err).NotTo(HaveOccurred())
+
+			By("switching to the new publication")
+			resource := switchPublication("publication_v2", nil)
+			Expect(resource.Status.RetainedVersions).To(ConsistOf(SatisfyAll(
+				HaveField("PublicationName", publicationName),
+				HaveField("OriginalTables", ContainElement(replication.PgTable{Schema: "published_data", Name: "people"})))))
+			expectTableExists(subscriberDB, "published_data", "people_publication_v1", expectedPeopleColumns)
+			Expect(replication.CheckSubscription(ctx, subscriberAdminDB, publicationName, "")).
+				To(MatchError(replication.ErrWrongAttributes))
+
+			By("rolling back to the previous publication")
+			resource = switchPublication(publicationName, nil)
+			Expect(resource.Status.RetainedVersions).To(ConsistOf(SatisfyAll(
+				HaveField("PublicationName", "publication_v2"),
+				HaveField("Tables", ConsistOf(replication.PgTable{Schema: "published_data", Name: "regions_publication_v2"})))))
+			expectTableExists(subscriberDB, "published_data", "people", expectedPeopleColumns)
+			expectTableExists(subscriberDB, "published_data", "cities", expectedCitiesColumns)
+			var exists bool
+			Expect(subscriberDB.QueryRow("SELECT to_regclass('published_data.people_publication_v1') IS NOT NULL").
+				Scan(&exists)).To(Succeed())
+			Expect(exists).To(BeFalse())
+			Expect(replication.CheckSubscription(ctx, subscriberAdminDB, publicationName, "")).To(Succeed())
+			Expect(replication.CheckSubscription(ctx, subscriberAdminDB, "publication_v2", "")).
+				To(MatchError(replication.ErrWrongAttributes))
+		})
+
+		It("should drop previous publications exceeding the retention", func() {
+			subscriberAdminDB := publishRegions()
+
+			By("Reconciling the current publication")
+			_, err := runReconcile(ctx, typeNamespacedName)
+			Expect(err).NotTo(HaveOccurred())
+
+			By("keeping the previous publication within the retention")
+			keepVersions := int32(1)
+			resource := switchPublication("publication_v2", &replicationv1alpha1.RetentionSpec{KeepVersions: &keepVersions})
+			Expect(resource.Status.RetainedVersions).To(ConsistOf(HaveField("PublicationName", publicationName)))
+			expectTableExists(subscriberDB, "published_data", "people_publication_v1", expectedPeopleColumns)
+
+			By("dropping the previous publication after it expires")
+			resource = switchPublication(publicationName, &replicationv1alpha1.RetentionSpec{
+				DropAfter: &metav1.Duration{Duration: 2 * time.Second},
+			})
+			Expect(resource.Status.RetainedVersions).To(ConsistOf(HaveField("PublicationName", "publication_v2")))
+
+			Eventually(func(g Gomega) {
+				_, err := runReconcile(ctx, typeNamespacedName)
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+				g.Expect(resource.Status.RetainedVersions).To(BeEmpty())
+			}, 10*time.Second, time.Second).Should(Succeed())
+
+			Expect(replication.CheckSubscription(ctx, subscriberAdminDB, "publication_v2", "")).
+				To(MatchError(sql.ErrNoRows))
+			var exists bool
+			Expect(subscriberDB.QueryRow("SELECT to_regclass('published_data.regions_publication_v2') IS NOT NULL").
+				Scan(&exists)).To(Succeed())
+			Expect(exists).To(BeFalse())
+		})
+
+		It("should record events of created objects", func() {
+			By("remove schema")
+			_, err := subscriberDB.Exec("DROP SCHEMA published_data CASCADE")
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Reconciling the created resource")
+			_, events, err := runReconcileWithEvents(ctx, typeNamespacedName)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(events).To(ContainElements(
+				"Normal CreatedSchema Created schema published_data",
+				"Normal CreatedTable Created table published_data.people",
+				"Normal CreatedTable Created table published_data.cities",
+				"Normal RefreshedSubscription Refreshed subscription "+publicationName,
+			))
+
+			By("Reconciling without drift")
+			_, events, err = runReconcileWithEvents(ctx, typeNamespacedName)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(events).NotTo(ContainElement(HavePrefix("Normal CreatedTable ")))
+		})
+
+		It("should record a failed reconciliation once", func() {
+			By("remove publication")
+			_, err := publisherDB.Exec("DROP PUBLICATION " + publicationName)
+			Expect(err).NotTo(HaveOccurred())
+			DeferCleanup(func() {
+				_, err := publisherDB.Exec("CREATE PUBLICATION " + publicationName)
+				Expect(err).NotTo(HaveOccurred())
+				_, err = publisherDB.Exec("ALTER PUBLICATION " + publicationName +
+					" ADD TABLE published_data.people (id, name), published_data.cities (id, name, zip, country)")
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			By("Reconciling the created resource")
+			_, events, err := runReconcileWithEvents(ctx, typeNamespacedName)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(events).To(ContainElement(SatisfyAll(
+				HavePrefix("Warning PublicationError "),
+				ContainSubstring("no rows in result set"))))
+
+			By("Reconciling the same failure")
+			_, events, err = runReconcileWithEvents(ctx, typeNamespacedName)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(events).NotTo(ContainElement(HavePrefix("Warning ")))
+		})
+
+		It("should describe the cause of a degraded subscription", func() {
+			publisherAdminDB, err := replication.DBConnect(ctx, generateDbCredentials("publisher").Admin(),
+				replication.Timeouts{})
+			Expect(err).NotTo(HaveOccurred())
+			DeferCleanup(publisherAdminDB.Close)
+
+			By("Reconciling the created resource")
+			_, err = runReconcile(ctx, typeNamespacedName)
+			Expect(err).NotTo(HaveOccurred())
+
+			By("dropping the replication slot")
+			Eventually(func(g Gomega) {
+				_, err := publisherAdminDB.Exec(`SELECT pg_terminate_backend(active_pid)
+												   FROM pg_replication_slots
+												  WHERE slot_name = $1 AND active_pid IS NOT NULL`, publicationName)
+				g.Expect(err).NotTo(HaveOccurred())
+				_, err = publisherAdminDB.Exec("SELECT pg_drop_replication_slot($1)", publicationName)
+				g.Expect(err).NotTo(HaveOccurred())
+			}, 10*time.Second, time.Second).Should(Succeed())
+			DeferCleanup(func() {
+				_, err := publisherAdminDB.Exec("SELECT pg_create_logical_replication_slot($1, 'pgoutput')", publicationName)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			resource := &replicationv1alpha1.LogicalReplication{}
+			Eventually(func(g Gomega) {
+				_, err := runReconcile(ctx, typeNamespacedName)
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+				degraded := meta.FindStatusCondition(resource.Status.Conditions, replicationv1alpha1.ConditionDegraded)
+				g.Expect(degraded).NotTo(BeNil())
+				g.Expect(degraded.Status).To(Equal(metav1.ConditionTrue))
+				g.Expect(degraded.Message).To(ContainSubstring(
+					"replication slot " + publicationName + " does not exist on the publisher"))
+			}, 30*time.Second, time.Second).Should(Succeed())
+		})
+
+		It("should stay degraded while the apply worker keeps failing", func() {
+			publisherAdminDB, err := replication.DBConnect(ctx, generateDbCredentials("publisher").Admin(),
+				replication.Timeouts{})
+			Expect(err).NotTo(HaveOccurred())
+			DeferCleanup(publisherAdminDB.Close)
+
+			By("Reconciling the created resource")
+			_, err = runReconcile(ctx, typeNamespacedName)
+			Expect(err).NotTo(HaveOccurred())
+
+			By("dropping the replication slot")
+			Eventually(func(g Gomega) {
+				_, err := publisherAdminDB.Exec(`SELECT pg_terminate_backend(active_pid)
+												   FROM pg_replication_slots
+												  WHERE slot_name = $1 AND active_pid IS NOT NULL`, publicationName)
+				g.Expect(err).NotTo(HaveOccurred())
+				_, err = publisherAdminDB.Exec("SELECT pg_drop_replication_slot($1)", publicationName)
+				g.Expect(err).NotTo(HaveOccurred())
+			}, 10*time.Second, time.Second).Should(Succeed())
+			DeferCleanup(func() {
+				_, err := publisherAdminDB.Exec("SELECT pg_create_logical_replication_slot($1, 'pgoutput')", publicationName)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			resource := &replicationv1alpha1.LogicalReplication{}
+			Eventually(func(g Gomega) {
+				_, err := runReconcile(ctx, typeNamespacedName)
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+				g.Expect(meta.IsStatusConditionTrue(resource.Status.Conditions, replicationv1alpha1.ConditionDegraded)).
+					To(BeTrue())
+			}, 30*time.Second, time.Second).Should(Succeed())
+
+			By("checking the subscription again")
+			for n := 0; n < 2; n++ {
+				result, events, err := runReconcileWithEvents(ctx, typeNamespacedName)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.RequeueAfter).To(BeNumerically(">=", degradedRetryPolicy.base))
+				Expect(events).NotTo(ContainElement(ContainSubstring(RecoveredEvent)))
+
+				Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+				Expect(meta.IsStatusConditionTrue(resource.Status.Conditions, replicationv1alpha1.ConditionDegraded)).
+					To(BeTrue())
+			}
+		})
+
+		It("should provision a dedicated replication role", func() {
+			By("enabling the replication role")
+			resource := &replicationv1alpha1.LogicalReplication{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			resource.Spec.Publication.ReplicationRole = &replicationv1alpha1.ReplicationRoleSpec{}
+			Expect(k8sClient.Update(ctx, resource)).To(Succeed())
+
+			By("Reconciling the created resource")
+			_, err := runReconcile(ctx, typeNamespacedName)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(resource.Finalizers).To(ContainElement(replicationRoleFinalizer))
+			Expect(resource.Status.ReplicationRole).NotTo(BeNil())
+			role := resource.Status.ReplicationRole.Name
+			Expect(replication.CheckReplicationRole(ctx, publisherDB, role)).To(Succeed())
+
+			secret := &corev1.Secret{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{
+				Namespace: typeNamespacedName.Namespace,
+				Name:      resource.Status.ReplicationRole.SecretName,
+			}, secret)).To(Succeed())
+			Expect(string(secret.Data["db.user"])).To(Equal(role))
+			// envtest doesn't collect the Secret owned by the deleted resource
+			DeferCleanup(func() {
+				Expect(k8sClient.Delete(ctx, secret)).To(Succeed())
+			})
+
+			By("dropping the subscription and the role with the resource")
+			iteration := NewLogicalReplicationIteration(k8sClient, nil, nil, nil, ctx,
+				reconcile.Request{NamespacedName: typeNamespacedName})
+			Expect(iteration.Finalize(resource)).To(Succeed())
+			Expect(replication.CheckReplicationRole(ctx, publisherDB, role)).To(MatchError(sql.ErrNoRows))
+			_, err = replication.SubscriptionSlotName(ctx, subscriberDB, publicationName)
+			Expect(err).To(MatchError(sql.ErrNoRows))
+			Expect(replication.CheckReplicationSlot(ctx, publisherDB, publicationName)).To(MatchError(sql.ErrNoRows))
+
+			controllerutil.RemoveFinalizer(resource, replicationRoleFinalizer)
+			Expect(k8sClient.Update(ctx, resource)).To(Succeed())
+		})
+
+		It("should refuse a replication role secret it doesn't control", func() {
+			By("creating the role's secret beforehand")
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      resourceName + "-replication-role",
+					Namespace: typeNamespacedName.Namespace,
+				},
+				Data: map[string][]byte{"db.user": []byte("foreign"), "db.password": []byte("foreign")},
+			}
+			Expect(k8sClient.Create(ctx, secret)).To(Succeed())
+			DeferCleanup(func() {
+				Expect(k8sClient.Delete(ctx, secret)).To(Succeed())
+			})
+
+			By("enabling the replication role")
+			resource := &replicationv1alpha1.LogicalReplication{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			resource.Spec.Publication.ReplicationRole = &replicationv1alpha1.ReplicationRoleSpec{}
+			Expect(k8sClient.Update(ctx, resource)).To(Succeed())
+
+			By("Reconciling the created resource")
+			result, err := runReconcile(ctx, typeNamespacedName)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(Equal(terminalRetryPolicy.base))
+
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(resource.Status.ReplicationStatus.Reason).To(Equal(string(ReplicationRoleError)))
+			Expect(resource.Status.ReplicationStatus.Hint).NotTo(BeEmpty())
+
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Namespace: secret.Namespace, Name: secret.Name}, secret)).To(Succeed())
+			Expect(string(secret.Data["db.user"])).To(Equal("foreign"))
+
+			controllerutil.RemoveFinalizer(resource, replicationRoleFinalizer)
+			Expect(k8sClient.Update(ctx, resource)).To(Succeed())
+		})
+
 		It("should fail when publication does not exist", func() {
 			By("remove publication")
 			_, err := publisherDB.Exec("DROP PUBLICATION " + publicationName)
@@ -275,7 +980,7 @@ var _ = Describe("LogicalReplication Controller", func() {
 			result, err := runReconcile(ctx, typeNamespacedName)
 
 			Expect(err).NotTo(HaveOccurred())
-			Expect(result.Requeue).To(BeTrue())
+			Expect(result.RequeueAfter).To(Equal(schemaRetryPolicy.base))
 		})
 
 		It("should fail when can't connect to subscriber db", func() {
@@ -287,7 +992,12 @@ var _ = Describe("LogicalReplication Controller", func() {
 			result, err := runReconcile(ctx, typeNamespacedName)
 
 			Expect(err).NotTo(HaveOccurred())
-			Expect(result.Requeue).To(BeTrue())
+			Expect(result.RequeueAfter).To(Equal(terminalRetryPolicy.base))
+
+			lr := &replicationv1alpha1.LogicalReplication{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, lr)).To(Succeed())
+			Expect(lr.Status.ReplicationStatus.Reason).To(Equal(string(AuthenticationFailed)))
+			Expect(lr.Status.ReplicationStatus.Hint).NotTo(BeEmpty())
 
 			_, err = subscriberDB.Exec("ALTER USER subscriber_user PASSWORD 'subscriber_password'")
 			Expect(err).NotTo(HaveOccurred())
@@ -302,10 +1012,84 @@ var _ = Describe("LogicalReplication Controller", func() {
 			result, err := runReconcile(ctx, typeNamespacedName)
 
 			Expect(err).NotTo(HaveOccurred())
-			Expect(result.Requeue).To(BeTrue())
+			Expect(result.RequeueAfter).To(Equal(terminalRetryPolicy.base))
+
+			lr := &replicationv1alpha1.LogicalReplication{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, lr)).To(Succeed())
+			Expect(lr.Status.ReplicationStatus.Reason).To(Equal(string(AuthenticationFailed)))
 
 			_, err = publisherDB.Exec("ALTER USER publisher_user PASSWORD 'publisher_password'")
 			Expect(err).NotTo(HaveOccurred())
 		})
 	})
+
+	Context("When the manager reconciles a failing resource", func() {
+		ctx := context.Background()
+		typeNamespacedName := types.NamespacedName{Name: "backoff-resource", Namespace: "default"}
+
+		It("should wait for the backoff before retrying", func() {
+			generateDbSecret(ctx, types.NamespacedName{Name: "publishing-database", Namespace: "default"}, "publisher")
+			generateDbSecret(ctx, types.NamespacedName{Name: "subscribing-database", Namespace: "default"}, "subscriber")
+
+			resource := &replicationv1alpha1.LogicalReplication{
+				ObjectMeta: metav1.ObjectMeta{Name: typeNamespacedName.Name, Namespace: typeNamespacedName.Namespace},
+				Spec: replicationv1alpha1.LogicalReplicationSpec{
+					Publication: replicationv1alpha1.PublicationSpec{
+						Name:       "missing_publication",
+						SecretName: "publishing-database",
+					},
+					Subscription: replicationv1alpha1.SubscriptionSpec{
+						SecretName: "subscribing-database",
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, resource)).To(Succeed())
+			DeferCleanup(func() {
+				Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+			})
+
+			By("failing the first reconciliation")
+			Eventually(func(g Gomega) {
+				g.Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+				g.Expect(resource.Status.Retry).NotTo(BeNil())
+				g.Expect(resource.Status.Retry.Attempts).To(Equal(int32(1)))
+			}, 10*time.Second, 100*time.Millisecond).Should(Succeed())
+			Expect(resource.Status.ReplicationStatus.Reason).To(Equal(string(PublicationError)))
+			Expect(resource.Status.Retry.NextRetryTime).NotTo(BeNil())
+			Expect(resource.Status.Retry.NextRetryTime.Time).To(BeTemporally("~",
+				time.Now().Add(schemaRetryPolicy.base), 10*time.Second))
+
+			By("not retrying before the backoff elapses")
+			Consistently(func(g Gomega) {
+				g.Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+				g.Expect(resource.Status.Retry.Attempts).To(Equal(int32(1)))
+			}, 5*time.Second, 500*time.Millisecond).Should(Succeed())
+		})
+	})
+})
+
+var _ = Describe("specChangedPredicate", func() {
+	newReplication := func(generation int64, annotations map[string]string) *replicationv1alpha1.LogicalReplication {
+		return &replicationv1alpha1.LogicalReplication{
+			ObjectMeta: metav1.ObjectMeta{Name: "replication", Generation: generation, Annotations: annotations},
+		}
+	}
+
+	It("should ignore status updates", func() {
+		old := newReplication(1, nil)
+		updated := newReplication(1, nil)
+		updated.Status.ReplicationStatus.Phase = replicationv1alpha1.ReplicationPhaseFailed
+		Expect(specChangedPredicate.Update(event.UpdateEvent{ObjectOld: old, ObjectNew: updated})).To(BeFalse())
+	})
+
+	It("should pass spec and annotation changes", func() {
+		Expect(specChangedPredicate.Update(event.UpdateEvent{
+			ObjectOld: newReplication(1, nil),
+			ObjectNew: newReplication(2, nil),
+		})).To(BeTrue())
+		Expect(specChangedPredicate.Update(event.UpdateEvent{
+			ObjectOld: newReplication(1, nil),
+			ObjectNew: newReplication(1, map[string]string{"example.com/resync": "now"}),
+		})).To(BeTrue())
+	})
 })
@@ -0,0 +1,167 @@
+package controller
+
+import (
+	"slices"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"github.com/RedHatInsights/pg-replication-operator/internal/replication"
+)
+
+const metricsNamespace = "logicalreplication"
+
+var metricLabels = []string{"namespace", "name", "subscription"}
+
+var (
+	receivedLSN = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "subscription_received_lsn_bytes",
+		Help:      "Last WAL location received by the subscription's apply worker",
+	}, metricLabels)
+	latestEndLSN = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "subscription_latest_end_lsn_bytes",
+		Help:      "Last WAL location reported to the publisher's walsender",
+	}, metricLabels)
+	lastMessageAge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "subscription_last_message_age_seconds",
+		Help:      "Time since the last message received from the publisher",
+	}, metricLabels)
+	// cumulative counters of pg_stat_subscription_stats are exported as gauges set to their value
+	applyErrors = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "subscription_apply_errors_total",
+		Help:      "Number of errors while applying changes, a monotonic counter reset with the subscription's statistics",
+	}, metricLabels)
+	syncErrors = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "subscription_sync_errors_total",
+		Help:      "Number of errors during the initial table synchronization, a monotonic counter reset with the subscription's statistics",
+	}, metricLabels)
+	conflicts = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "subscription_conflicts_total",
+		Help:      "Number of conflicts while applying changes by conflict type, a monotonic counter reset with the subscription's statistics",
+	}, append(metricLabels, "type"))
+	slotActive = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "slot_active",
+		Help:      "Whether the publisher's replication slot is in use",
+	}, metricLabels)
+	slotWalStatus = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "slot_wal_status",
+		Help:      "Availability of WAL claimed by the publisher's replication slot, set to 1 for the current status",
+	}, append(metricLabels, "status"))
+	slotConfirmedFlushLag = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "slot_confirmed_flush_lag_bytes",
+		Help:      "WAL written on the publisher not confirmed by the subscriber",
+	}, metricLabels)
+	slotReplayLag = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "slot_replay_lag_seconds",
+		Help:      "Time until the subscriber applied recent WAL as reported by the publisher",
+	}, metricLabels)
+
+	subscriptionMetrics = []*prometheus.GaugeVec{
+		receivedLSN,
+		latestEndLSN,
+		lastMessageAge,
+		applyErrors,
+		syncErrors,
+		conflicts,
+	}
+	slotMetrics = []*prometheus.GaugeVec{
+		slotActive,
+		slotWalStatus,
+		slotConfirmedFlushLag,
+		slotReplayLag,
+	}
+	replicationMetrics = append(slices.Clone(subscriptionMetrics), slotMetrics...)
+
+	// wal_status values of pg_replication_slots exported as a state set
+	walStatuses = []string{"reserved", "extended", "unreserved", "lost"}
+
+	// subscription label of the last exported series by namespace/name of the LogicalReplication
+	metricSubscriptions sync.Map
+)
+
+func init() {
+	for _, m := range replicationMetrics {
+		metrics.Registry.MustRegister(m)
+	}
+}
+
+// deleteMetrics removes all series of the LogicalReplication
+func deleteMetrics(namespace, name string) {
+	metricSubscriptions.Delete(namespace + "/" + name)
+	deleteGroupMetrics(replicationMetrics, prometheus.Labels{"namespace": namespace, "name": name})
+}
+
+// deleteGroupMetrics removes series of the metrics matching the labels
+func deleteGroupMetrics(group []*prometheus.GaugeVec, labels prometheus.Labels) {
+	for _, m := range group {
+		m.DeletePartialMatch(labels)
+	}
+}
+
+// trackSubscription removes series of the subscription the LogicalReplication exported before,
+// series of the current subscription are overwritten so they never disappear from scrapes
+func trackSubscription(labels prometheus.Labels) {
+	key := labels["namespace"] + "/" + labels["name"]
+	previous, loaded := metricSubscriptions.Swap(key, labels["subscription"])
+	if loaded && previous != labels["subscription"] {
+		deleteGroupMetrics(replicationMetrics, prometheus.Labels{
+			"namespace":    labels["namespace"],
+			"name":         labels["name"],
+			"subscription": previous.(string),
+		})
+	}
+}
+
+func setOptionalGauge(gauge *prometheus.GaugeVec, labels prometheus.Labels, value float64, valid bool) {
+	if valid {
+		gauge.With(labels).Set(value)
+	} else {
+		gauge.Delete(labels)
+	}
+}
+
+func boolGauge(value bool) float64 {
+	if value {
+		return 1
+	}
+	return 0
+}
+
+func setSubscriptionMetrics(labels prometheus.Labels, stats replication.SubscriptionStats) {
+	setOptionalGauge(receivedLSN, labels, stats.ReceivedLSN.Float64, stats.ReceivedLSN.Valid)
+	setOptionalGauge(latestEndLSN, labels, stats.LatestEndLSN.Float64, stats.LatestEndLSN.Valid)
+	setOptionalGauge(lastMessageAge, labels, stats.LastMessageAge.Float64, stats.LastMessageAge.Valid)
+	applyErrors.With(labels).Set(float64(stats.ApplyErrors))
+	syncErrors.With(labels).Set(float64(stats.SyncErrors))
+	for conflictType, count := range stats.Conflicts {
+		conflicts.MustCurryWith(labels).WithLabelValues(conflictType).Set(float64(count))
+	}
+}
+
+func setSlotMetrics(labels prometheus.Labels, stats replication.SlotStats) {
+	slotActive.With(labels).Set(boolGauge(stats.Active))
+	walStatus := slotWalStatus.MustCurryWith(labels)
+	if !stats.WalStatus.Valid {
+		slotWalStatus.DeletePartialMatch(labels)
+	} else {
+		for _, status := range walStatuses {
+			walStatus.WithLabelValues(status).Set(boolGauge(status == stats.WalStatus.String))
+		}
+		if !slices.Contains(walStatuses, stats.WalStatus.String) {
+			walStatus.WithLabelValues(stats.WalStatus.String).Set(1)
+		}
+	}
+	setOptionalGauge(slotConfirmedFlushLag, labels, stats.ConfirmedFlushLag.Float64, stats.ConfirmedFlushLag.Valid)
+	setOptionalGauge(slotReplayLag, labels, stats.ReplayLag.Float64, stats.ReplayLag.Valid)
+}
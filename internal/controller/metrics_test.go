@@ -0,0 +1,109 @@
+package controller
+
+import (
+	"database/sql"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/RedHatInsights/pg-replication-operator/internal/replication"
+)
+
+// countSeries counts series of the gauge matching the labels, other replications
+// of the suite export series of the same gauges
+func countSeries(gauge *prometheus.GaugeVec, labels prometheus.Labels) int {
+	GinkgoHelper()
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		gauge.Collect(ch)
+		close(ch)
+	}()
+
+	count := 0
+	for metric := range ch {
+		series := &dto.Metric{}
+		Expect(metric.Write(series)).To(Succeed())
+		matching := 0
+		for _, pair := range series.GetLabel() {
+			if value, ok := labels[pair.GetName()]; ok && value == pair.GetValue() {
+				matching++
+			}
+		}
+		if matching == len(labels) {
+			count++
+		}
+	}
+	return count
+}
+
+var _ = Describe("Metrics", func() {
+	replicationLabels := prometheus.Labels{"namespace": "metrics", "name": "replication"}
+	labels := prometheus.Labels{"namespace": "metrics", "name": "replication", "subscription": "publication_v1"}
+
+	AfterEach(func() {
+		deleteMetrics("metrics", "replication")
+	})
+
+	It("should overwrite values of the subscription", func() {
+		trackSubscription(labels)
+		setSubscriptionMetrics(labels, replication.SubscriptionStats{
+			ReceivedLSN: sql.NullFloat64{Float64: 100, Valid: true},
+			ApplyErrors: 1,
+		})
+		setSubscriptionMetrics(labels, replication.SubscriptionStats{
+			ReceivedLSN: sql.NullFloat64{Float64: 200, Valid: true},
+			ApplyErrors: 2,
+		})
+
+		Expect(testutil.ToFloat64(receivedLSN.With(labels))).To(Equal(200.0))
+		Expect(testutil.ToFloat64(applyErrors.With(labels))).To(Equal(2.0))
+	})
+
+	It("should remove optional values once they are unknown", func() {
+		setSubscriptionMetrics(labels, replication.SubscriptionStats{
+			LastMessageAge: sql.NullFloat64{Float64: 5, Valid: true},
+		})
+		Expect(countSeries(lastMessageAge, replicationLabels)).To(Equal(1))
+
+		setSubscriptionMetrics(labels, replication.SubscriptionStats{})
+		Expect(countSeries(lastMessageAge, replicationLabels)).To(Equal(0))
+	})
+
+	It("should export the slot's wal status as a state set", func() {
+		setSlotMetrics(labels, replication.SlotStats{WalStatus: sql.NullString{String: "reserved", Valid: true}})
+		setSlotMetrics(labels, replication.SlotStats{WalStatus: sql.NullString{String: "lost", Valid: true}})
+
+		walStatus := slotWalStatus.MustCurryWith(labels)
+		Expect(countSeries(slotWalStatus, replicationLabels)).To(Equal(len(walStatuses)))
+		Expect(testutil.ToFloat64(walStatus.WithLabelValues("lost"))).To(Equal(1.0))
+		Expect(testutil.ToFloat64(walStatus.WithLabelValues("reserved"))).To(Equal(0.0))
+	})
+
+	It("should remove series of the previous subscription", func() {
+		trackSubscription(labels)
+		setSubscriptionMetrics(labels, replication.SubscriptionStats{ApplyErrors: 1})
+		setSlotMetrics(labels, replication.SlotStats{Active: true})
+
+		renamed := prometheus.Labels{"namespace": "metrics", "name": "replication", "subscription": "publication_v2"}
+		trackSubscription(renamed)
+		setSubscriptionMetrics(renamed, replication.SubscriptionStats{ApplyErrors: 3})
+
+		Expect(countSeries(applyErrors, replicationLabels)).To(Equal(1))
+		Expect(testutil.ToFloat64(applyErrors.With(renamed))).To(Equal(3.0))
+		Expect(countSeries(slotActive, replicationLabels)).To(Equal(0))
+	})
+
+	It("should remove all series of a deleted replication", func() {
+		trackSubscription(labels)
+		setSubscriptionMetrics(labels, replication.SubscriptionStats{ApplyErrors: 1})
+		setSlotMetrics(labels, replication.SlotStats{Active: true})
+
+		deleteMetrics("metrics", "replication")
+		Expect(countSeries(applyErrors, replicationLabels)).To(Equal(0))
+		Expect(countSeries(slotActive, replicationLabels)).To(Equal(0))
+	})
+})
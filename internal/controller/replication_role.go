@@ -0,0 +1,284 @@
+package controller
+
+import (
+	"database/sql"
+	"fmt"
+	"slices"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	replicationv1alpha1 "github.com/RedHatInsights/pg-replication-operator/api/v1alpha1"
+	"github.com/RedHatInsights/pg-replication-operator/internal/replication"
+)
+
+// replicationRoleFinalizer drops the replication role on the publisher with the LogicalReplication
+const replicationRoleFinalizer = "replication.console.redhat.com/replication-role"
+
+// passwordRotatedAtAnnotation of the role's Secret keeps the time its password was generated
+const passwordRotatedAtAnnotation = "replication.console.redhat.com/password-rotated-at"
+
+func replicationRoleName(lr *replicationv1alpha1.LogicalReplication) string {
+	if spec := lr.Spec.Publication.ReplicationRole; spec != nil && spec.Name != "" {
+		return spec.Name
+	}
+	if lr.Status.ReplicationRole != nil && lr.Spec.Publication.ReplicationRole == nil {
+		return lr.Status.ReplicationRole.Name
+	}
+	return replication.TruncateIdentifier(fmt.Sprintf("%s_%s_replication", lr.Namespace, lr.Name))
+}
+
+func replicationRoleSecretName(lr *replicationv1alpha1.LogicalReplication) string {
+	return lr.Name + "-replication-role"
+}
+
+// replicationRoleSecret reads the role's password from its Secret, a missing Secret is created
+// and a password due for rotation is replaced, it returns the time the password was generated
+func (i *LogicalReplicationIteration) replicationRoleSecret(name string) (string, metav1.Time, error) {
+	spec := i.obj.Spec.Publication.ReplicationRole
+	secret := &corev1.Secret{}
+	nn := types.NamespacedName{Namespace: i.obj.Namespace, Name: replicationRoleSecretName(i.obj)}
+	err := i.Client.Get(i.ctx, nn, secret)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return "", metav1.Time{}, err
+	}
+	exists := err == nil
+	if exists && !metav1.IsControlledBy(secret, i.obj) {
+		replerr := NewReplicationError(ReplicationRoleError,
+			fmt.Errorf("secret %s is not controlled by the LogicalReplication", nn.Name))
+		replerr.Transient = false
+		replerr.Hint = "delete or rename the Secret, the operator creates the replication role's Secret"
+		return "", metav1.Time{}, replerr
+	}
+
+	rotatedAt, parseErr := time.Parse(time.RFC3339, secret.Annotations[passwordRotatedAtAnnotation])
+	password := string(secret.Data["db.password"])
+	due := spec.RotationInterval != nil && time.Since(rotatedAt) >= spec.RotationInterval.Duration
+	if exists && parseErr == nil && password != "" && string(secret.Data["db.user"]) == name && !due {
+		return password, metav1.NewTime(rotatedAt), nil
+	}
+
+	if password, err = replication.GeneratePassword(); err != nil {
+		return "", metav1.Time{}, err
+	}
+	now := metav1.Now().Rfc3339Copy()
+	secret.Name, secret.Namespace = nn.Name, nn.Namespace
+	if secret.Annotations == nil {
+		secret.Annotations = make(map[string]string)
+	}
+	secret.Annotations[passwordRotatedAtAnnotation] = now.Format(time.RFC3339)
+	secret.Data = map[string][]byte{
+		"db.user":     []byte(name),
+		"db.password": []byte(password),
+	}
+	if err = controllerutil.SetControllerReference(i.obj, secret, i.Client.Scheme()); err != nil {
+		return "", metav1.Time{}, err
+	}
+
+	if exists {
+		err = i.Client.Update(i.ctx, secret)
+	} else {
+		err = i.Client.Create(i.ctx, secret)
+	}
+	if err != nil {
+		return "", metav1.Time{}, err
+	}
+	if exists {
+		i.event(RotatedPasswordEvent, "Rotated password of replication role %s", name)
+	}
+	return password, now, nil
+}
+
+// checkReplicationRole creates the role the subscription connects as and grants it
+// the published tables, a new password is set on the role before the subscription uses it
+func (i *LogicalReplicationIteration) checkReplicationRole() error {
+	if i.obj.Spec.Publication.ReplicationRole == nil {
+		return nil
+	}
+	name := replicationRoleName(i.obj)
+
+	password, rotatedAt, err := i.replicationRoleSecret(name)
+	if replerr, ok := err.(ReplicationError); ok {
+		return replerr
+	}
+	if err != nil {
+		i.log.Error(err, "reading replication role secret", "role", name)
+		return NewReplicationError(ReplicationRoleError, err)
+	}
+
+	previous := i.obj.Status.ReplicationRole
+	applied := previous != nil && previous.Name == name && previous.PasswordRotatedAt.Equal(&rotatedAt)
+	err = replication.CheckReplicationRole(i.ctx, i.pubAdminDB, name)
+	switch {
+	case err == sql.ErrNoRows:
+		if err = replication.CreateReplicationRole(i.ctx, i.pubAdminDB, name, password); err != nil {
+			i.log.Error(err, "creating", "role", name)
+			return NewReplicationError(ReplicationRoleError, err)
+		}
+		i.log.Info("created", "role", name)
+		i.event(CreatedReplicationRoleEvent, "Created replication role %s", name)
+
+	case err == replication.ErrWrongAttributes || (err == nil && !applied):
+		if err = replication.AlterReplicationRole(i.ctx, i.pubAdminDB, name, password); err != nil {
+			i.log.Error(err, "altering", "role", name)
+			return NewReplicationError(ReplicationRoleError, err)
+		}
+		i.log.Info("altered", "role", name)
+		i.event(AlteredReplicationRoleEvent, "Set password of replication role %s", name)
+
+	case err != nil:
+		i.log.Error(err, "checking", "role", name)
+		return NewReplicationError(ReplicationRoleError, err)
+	}
+
+	missing, err := replication.CheckReplicationRoleGrants(i.ctx, i.pubAdminDB, name, i.tables)
+	if err != nil {
+		i.log.Error(err, "checking grants", "role", name)
+		return NewReplicationError(ReplicationRoleError, err)
+	}
+	for _, table := range missing {
+		if err = replication.GrantReplicationRoleTable(i.ctx, i.pubAdminDB, name, table); err != nil {
+			i.log.Error(err, "granting", "role", name, "schema", table.Schema, "table", table.Name)
+			return NewReplicationError(ReplicationRoleError, err)
+		}
+		i.event(GrantedReplicationRoleEvent, "Granted SELECT on %s.%s to replication role %s",
+			table.Schema, table.Name, name)
+	}
+
+	i.replCreds = i.pubCreds
+	i.replCreds.User, i.replCreds.Password = name, password
+	i.replicationRole = &replicationv1alpha1.ReplicationRoleStatus{
+		Name:              name,
+		SecretName:        replicationRoleSecretName(i.obj),
+		PasswordRotatedAt: rotatedAt,
+	}
+	if previous != nil && previous.Name != name {
+		i.retiredRole = previous.Name
+	}
+	return nil
+}
+
+// dropRetiredRole drops the role replaced by a role of a different name
+// once the subscription connects as the new one
+func (i *LogicalReplicationIteration) dropRetiredRole() error {
+	if i.retiredRole == "" {
+		return nil
+	}
+	if err := replication.DropReplicationRole(i.ctx, i.pubAdminDB, i.retiredRole); err != nil {
+		i.log.Error(err, "dropping", "role", i.retiredRole)
+		return NewReplicationError(ReplicationRoleError, err)
+	}
+	i.event(DroppedReplicationRoleEvent, "Dropped replication role %s", i.retiredRole)
+	return nil
+}
+
+// rotationDue is the time until the role's password is rotated, zero when it isn't rotated
+func (i *LogicalReplicationIteration) rotationDue() time.Duration {
+	spec := i.obj.Spec.Publication.ReplicationRole
+	if spec == nil || spec.RotationInterval == nil || i.replicationRole == nil {
+		return 0
+	}
+	return max(time.Until(i.replicationRole.PasswordRotatedAt.Add(spec.RotationInterval.Duration)), time.Second)
+}
+
+// Finalize drops the subscriptions and the replication role of the deleted LogicalReplication,
+// the role is left behind when the publisher's Secret is gone
+func (i *LogicalReplicationIteration) Finalize(lr *replicationv1alpha1.LogicalReplication) error {
+	i.log = log.FromContext(i.ctx)
+	i.obj = lr
+	name := replicationRoleName(lr)
+
+	creds, err := i.getCredentialsFromSecret(lr.Spec.Publication.SecretName)
+	if apierrors.IsNotFound(err) {
+		i.log.Info("publisher secret is gone, leaving replication role behind", "role", name)
+		return nil
+	}
+	if err != nil {
+		return NewReplicationError(SecretError, err)
+	}
+
+	defer i.closeDBs()
+	if i.pubAdminDB, err = i.connectDB(lr.Spec.Publication.SecretName+"/admin", creds.Admin()); err != nil {
+		return err
+	}
+
+	// the subscriptions connect as the role, they are dropped with their slots first
+	if err = i.dropSubscriptions(); err != nil {
+		return err
+	}
+
+	if err = replication.DropReplicationRole(i.ctx, i.pubAdminDB, name); err != nil {
+		i.log.Error(err, "dropping", "role", name)
+		return NewReplicationError(ReplicationRoleError, err)
+	}
+	i.log.Info("dropped", "role", name)
+	return nil
+}
+
+// dropSubscriptions drops the subscriptions of the current and the retained versions,
+// slots left behind by a subscriber that's gone are dropped on the publisher
+func (i *LogicalReplicationIteration) dropSubscriptions() error {
+	names := []string{i.subscriptionName(i.obj.Spec.Publication.Name)}
+	if i.obj.Status.ReconciledValues.PublicationName != "" {
+		names = append(names, i.oldSubscriptionName())
+	}
+	for _, version := range i.obj.Status.RetainedVersions {
+		names = append(names, i.versionSubscriptionName(version))
+	}
+	slices.Sort(names)
+	names = slices.Compact(names)
+
+	creds, err := i.getCredentialsFromSecret(i.obj.Spec.Subscription.SecretName)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return NewReplicationError(SecretError, err)
+	}
+	if err == nil {
+		key := i.obj.Spec.Subscription.SecretName
+		if creds.Admin() != creds {
+			key += "/admin"
+		}
+		if i.subAdminDB, err = i.connectDB(key, creds.Admin()); err != nil {
+			return err
+		}
+	} else {
+		i.log.Info("subscriber secret is gone, dropping replication slots of its subscriptions")
+	}
+
+	for idx, subname := range names {
+		if i.subAdminDB == nil {
+			break
+		}
+		// a renamed subscription keeps the slot of its previous name
+		slot, err := replication.SubscriptionSlotName(i.ctx, i.subAdminDB, subname)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			i.log.Error(err, "reading slot", "subscription", subname)
+			return NewReplicationError(SubscriptionError, err)
+		}
+		if slot != "" {
+			names[idx] = slot
+		}
+		if err = replication.DropSubscription(i.ctx, i.subAdminDB, subname); err != nil {
+			i.log.Error(err, "dropping", "subscription", subname)
+			return NewReplicationError(SubscriptionError, err)
+		}
+		i.log.Info("dropped", "subscription", subname)
+	}
+
+	// the subscription drops its slot unless the publisher was unreachable,
+	// a slot still in use fails and the finalizer is retried
+	for _, slot := range names {
+		if err := replication.DropReplicationSlot(i.ctx, i.pubAdminDB, slot); err != nil {
+			i.log.Error(err, "dropping", "slot", slot)
+			return NewReplicationError(SubscriptionError, err)
+		}
+	}
+	return nil
+}
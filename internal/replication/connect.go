@@ -1,38 +1,123 @@
 package replication
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
 	"net/url"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/lib/pq"
 )
 
 var ErrWrongAttributes = errors.New("wrong attributes")
+var ErrMissingPrivilege = errors.New("missing privilege to create subscription")
+
+// SubscriptionRelReady is the pg_subscription_rel state of a table
+// which finished its initial synchronization
+var SubscriptionRelReady = "r"
 
 func CredentialsToConnectionString(credentials DatabaseCredentials) string {
-	return fmt.Sprintf("postgresql://%s:%s@%s:%s/%s?sslmode=%s",
+	return credentialsURL(credentials) + "?sslmode=" + url.QueryEscape("disable")
+}
+
+func credentialsURL(credentials DatabaseCredentials) string {
+	return fmt.Sprintf("postgresql://%s:%s@%s:%s/%s",
 		url.QueryEscape(credentials.User),
 		url.QueryEscape(credentials.Password),
 		url.QueryEscape(credentials.Host),
 		url.QueryEscape(credentials.Port),
-		url.QueryEscape(credentials.DatabaseName),
-		url.QueryEscape("disable"))
+		url.QueryEscape(credentials.DatabaseName))
+}
+
+// SubscriberConnection overrides the publisher's credentials in the subscription's connection string
+// when the subscriber's server reaches the publisher differently than the operator
+type SubscriberConnection struct {
+	Host   string
+	Port   string
+	Params map[string]string
+}
+
+// parameters of the connection string given by the credentials
+var credentialsParams = []string{"user", "password", "dbname", "host", "hostaddr", "port"}
+
+// SubscriptionConnectionString returns the connection string of the subscription
+func SubscriptionConnectionString(credentials DatabaseCredentials, conn SubscriberConnection) (string, error) {
+	if conn.Host != "" {
+		credentials.Host = conn.Host
+	}
+	if conn.Port != "" {
+		credentials.Port = conn.Port
+	}
+	if len(conn.Params) == 0 {
+		return CredentialsToConnectionString(credentials), nil
+	}
+
+	params := url.Values{"sslmode": {"disable"}}
+	for key, value := range conn.Params {
+		if slices.Contains(credentialsParams, strings.ToLower(key)) {
+			return "", fmt.Errorf("connection parameter %s can't be overridden", key)
+		}
+		params.Set(key, value)
+	}
+	return credentialsURL(credentials) + "?" + params.Encode(), nil
 }
 
-func DBConnect(credentials DatabaseCredentials) (*sql.DB, error) {
-	connStr := CredentialsToConnectionString(credentials)
+// sameConnection compares connection strings regardless of the order of their parameters
+func sameConnection(a, b string) bool {
+	if a == b {
+		return true
+	}
+	urlA, errA := url.Parse(a)
+	urlB, errB := url.Parse(b)
+	if errA != nil || errB != nil {
+		return false
+	}
+	return urlA.Scheme == urlB.Scheme && urlA.User.String() == urlB.User.String() &&
+		urlA.Host == urlB.Host && urlA.Path == urlB.Path &&
+		reflect.DeepEqual(urlA.Query(), urlB.Query())
+}
+
+// Timeouts of statements of the operator's sessions, the lock timeout
+// keeps DDL from queuing behind long-running transactions of applications
+// and blocking their queries meanwhile, zero keeps the database's setting
+type Timeouts struct {
+	Statement time.Duration
+	Lock      time.Duration
+}
+
+// sessionConnectionString sets the timeouts as runtime parameters of the operator's sessions
+func sessionConnectionString(credentials DatabaseCredentials, timeouts Timeouts) string {
+	params := url.Values{}
+	if timeouts.Statement > 0 {
+		params.Set("statement_timeout", strconv.FormatInt(timeouts.Statement.Milliseconds(), 10))
+	}
+	if timeouts.Lock > 0 {
+		params.Set("lock_timeout", strconv.FormatInt(timeouts.Lock.Milliseconds(), 10))
+	}
+	if len(params) == 0 {
+		return CredentialsToConnectionString(credentials)
+	}
+	return CredentialsToConnectionString(credentials) + "&" + params.Encode()
+}
+
+func DBConnect(ctx context.Context, credentials DatabaseCredentials, timeouts Timeouts) (*sql.DB, error) {
+	connStr := sessionConnectionString(credentials, timeouts)
 	db, err := sql.Open("postgres", connStr)
 	if err != nil {
 		return nil, err
 	}
-	err = db.Ping()
+	err = db.PingContext(ctx)
 	return db, err
 }
 
-func CheckPublication(db *sql.DB, name string) error {
-	row := db.QueryRow(`SELECT p.puballtables,
+func CheckPublication(ctx context.Context, db Querier, name string) error {
+	row := db.QueryRowContext(ctx, `SELECT p.puballtables,
 							   (p.pubinsert AND p.pubupdate AND p.pubdelete AND p.pubtruncate) as pubops,
 							   (SELECT COUNT(*) FROM pg_publication_namespace pn WHERE p.oid = pn.pnpubid) as pubnamespaces
 						  FROM pg_publication p
@@ -63,34 +148,149 @@ func CheckPublication(db *sql.DB, name string) error {
 	return nil
 }
 
-func CreateSubscription(db *sql.DB, name string, connStr string) error {
-	sql := fmt.Sprintf(`CREATE SUBSCRIPTION %s CONNECTION %s PUBLICATION %s WITH (connect=false);`,
+// CheckCreateSubscriptionPrivilege returns ErrMissingPrivilege unless the user is a superuser,
+// or a member of pg_create_subscription with CREATE on the database since PostgreSQL 16
+func CheckCreateSubscriptionPrivilege(ctx context.Context, db Querier) error {
+	row := db.QueryRowContext(ctx, `SELECT r.rolsuper
+									  OR CASE WHEN current_setting('server_version_num')::int >= 160000
+											  THEN pg_has_role(current_user, 'pg_create_subscription', 'USAGE')
+												   AND has_database_privilege(current_database(), 'CREATE')
+											  ELSE false
+										 END
+								 FROM pg_roles r
+								WHERE r.rolname = current_user`)
+	var allowed bool
+	if err := row.Scan(&allowed); err != nil {
+		return err
+	}
+	if !allowed {
+		return ErrMissingPrivilege
+	}
+	return nil
+}
+
+// CreateSubscription creates disabled subscription of the publication, its replication slot
+// is named after the subscription, it has to be created before the subscription is enabled
+func CreateSubscription(ctx context.Context, db *sql.DB, name string, pubname string, connStr string) error {
+	sql := fmt.Sprintf(`CREATE SUBSCRIPTION %s CONNECTION %s PUBLICATION %s WITH (connect=false, slot_name=%s);`,
 		pq.QuoteIdentifier(name),
 		pq.QuoteLiteral(connStr),
-		pq.QuoteIdentifier(name))
-	_, err := db.Exec(sql)
+		pq.QuoteIdentifier(pubname),
+		pq.QuoteLiteral(name))
+	_, err := db.ExecContext(ctx, sql)
 	return err
 }
 
-func AlterSubscription(db *sql.DB, name string, connStr string) error {
+// SubscriptionSlotName reads the replication slot of the subscription, a renamed subscription
+// keeps the slot of its previous name, the name is empty when the subscription has no slot
+func SubscriptionSlotName(ctx context.Context, db Querier, name string) (string, error) {
+	var slot sql.NullString
+	err := db.QueryRowContext(ctx, `SELECT subslotname FROM pg_subscription WHERE subname = $1`, name).Scan(&slot)
+	return slot.String, err
+}
+
+// RenameSubscription renames the subscription, its replication slot isn't renamed
+func RenameSubscription(ctx context.Context, db Querier, name string, newName string) error {
+	sql := fmt.Sprintf(`ALTER SUBSCRIPTION %s RENAME TO %s`, pq.QuoteIdentifier(name), pq.QuoteIdentifier(newName))
+	_, err := db.ExecContext(ctx, sql)
+	return err
+}
+
+// CheckReplicationSlot returns sql.ErrNoRows when the publisher has no slot of the name
+func CheckReplicationSlot(ctx context.Context, db Querier, name string) error {
+	var exists bool
+	return db.QueryRowContext(ctx, `SELECT true FROM pg_replication_slots WHERE slot_name = $1`, name).Scan(&exists)
+}
+
+// DropReplicationSlot drops the slot of the name if it exists, a slot used by a connection can't be dropped
+func DropReplicationSlot(ctx context.Context, db Querier, name string) error {
+	_, err := db.ExecContext(ctx, `SELECT pg_drop_replication_slot(slot_name) FROM pg_replication_slots WHERE slot_name = $1`, name)
+	return err
+}
+
+// CreateReplicationSlot creates the logical replication slot of a subscription created without connecting
+// to the publisher, a subscriber on the publisher's cluster would wait for its own transaction otherwise
+func CreateReplicationSlot(ctx context.Context, db Querier, name string) error {
+	_, err := db.ExecContext(ctx, `SELECT pg_create_logical_replication_slot($1, 'pgoutput')`, name)
+	return err
+}
+
+func EnableSubscription(ctx context.Context, db Querier, name string) error {
+	sql := fmt.Sprintf("ALTER SUBSCRIPTION %s ENABLE", pq.QuoteIdentifier(name))
+	_, err := db.ExecContext(ctx, sql)
+	return err
+}
+
+func AlterSubscription(ctx context.Context, db Querier, name string, connStr string) error {
 	sql := fmt.Sprintf("ALTER SUBSCRIPTION %s CONNECTION %s", pq.QuoteIdentifier(name), pq.QuoteLiteral(connStr))
-	_, err := db.Exec(sql)
+	_, err := db.ExecContext(ctx, sql)
 	if err != nil {
 		return err
 	}
-	sql = fmt.Sprintf("ALTER SUBSCRIPTION %s ENABLE", pq.QuoteIdentifier(name))
-	_, err = db.Exec(sql)
+	return EnableSubscription(ctx, db, name)
+}
+
+func RefreshSubscription(ctx context.Context, db *sql.DB, name string) error {
+	sql := fmt.Sprintf("ALTER SUBSCRIPTION %s REFRESH PUBLICATION", pq.QuoteIdentifier(name))
+	_, err := db.ExecContext(ctx, sql)
 	return err
 }
 
-func DisableSubscription(db *sql.DB, name string) error {
+// SubscriptionTableStates reads synchronization states of the subscription's tables,
+// partitions are listed instead of partitioned tables not published via their root
+func SubscriptionTableStates(ctx context.Context, db Querier, name string) (map[PgTable]string, error) {
+	rows, err := db.QueryContext(ctx, `SELECT n.nspname, c.relname, sr.srsubstate
+							 FROM pg_subscription s
+							 JOIN pg_subscription_rel sr ON sr.srsubid = s.oid
+							 JOIN pg_class c ON sr.srrelid = c.oid
+							 JOIN pg_namespace n ON c.relnamespace = n.oid
+							WHERE s.subname = $1`, name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	states := make(map[PgTable]string)
+	for rows.Next() {
+		var (
+			table PgTable
+			state string
+		)
+		if err = rows.Scan(&table.Schema, &table.Name, &state); err != nil {
+			return nil, err
+		}
+		states[table] = state
+	}
+	return states, rows.Err()
+}
+
+// SubscriptionReplicatesTable reports whether the subscription replicates the table or its partitions
+func SubscriptionReplicatesTable(ctx context.Context, db Querier, name string, table PgTable) (bool, error) {
+	var replicated bool
+	err := db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1
+													 FROM pg_subscription s
+													 JOIN pg_subscription_rel sr ON sr.srsubid = s.oid
+													WHERE s.subname = $1
+													  AND sr.srrelid IN (SELECT relid FROM pg_partition_tree(to_regclass($2))))`,
+		name, pq.QuoteIdentifier(table.Schema)+"."+pq.QuoteIdentifier(table.Name)).Scan(&replicated)
+	return replicated, err
+}
+
+func DisableSubscription(ctx context.Context, db Querier, name string) error {
 	sql := fmt.Sprintf("ALTER SUBSCRIPTION %s DISABLE", pq.QuoteIdentifier(name))
-	_, err := db.Exec(sql)
+	_, err := db.ExecContext(ctx, sql)
+	return err
+}
+
+// DropSubscription drops the subscription together with its replication slot on the publisher
+func DropSubscription(ctx context.Context, db *sql.DB, name string) error {
+	sql := fmt.Sprintf("DROP SUBSCRIPTION IF EXISTS %s", pq.QuoteIdentifier(name))
+	_, err := db.ExecContext(ctx, sql)
 	return err
 }
 
-func CheckSubscription(db *sql.DB, name string, connStr string) error {
-	row := db.QueryRow(`SELECT s.subenabled,
+func CheckSubscription(ctx context.Context, db Querier, name string, connStr string) error {
+	row := db.QueryRowContext(ctx, `SELECT s.subenabled,
 							   s.subconninfo
 						  FROM pg_subscription s
 						 WHERE s.subname = $1`, name)
@@ -106,7 +306,7 @@ func CheckSubscription(db *sql.DB, name string, connStr string) error {
 
 	// subscription should be enabled
 	// and have correct connection info
-	if !subenabled || (connStr != "" && subconninfo != connStr) {
+	if !subenabled || (connStr != "" && !sameConnection(subconninfo, connStr)) {
 		return ErrWrongAttributes
 	}
 
@@ -0,0 +1,176 @@
+package replication
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+type ConstraintType string
+
+var (
+	CheckConstraint      ConstraintType = "c"
+	UniqueConstraint     ConstraintType = "u"
+	PrimaryKeyConstraint ConstraintType = "p"
+	ForeignKeyConstraint ConstraintType = "f"
+)
+
+type PgConstraint struct {
+	Name       string
+	Type       ConstraintType
+	Definition string
+	// table referenced by a foreign key, empty for other constraint types
+	Reference PgTable
+	// type of the referenced table's constraint a foreign key depends on,
+	// empty when the referenced columns have only a unique index
+	ReferencedKey ConstraintType
+}
+
+const (
+	notValidClause   = " NOT VALID"
+	deferrableClause = " DEFERRABLE"
+	deferredClause   = " DEFERRABLE INITIALLY DEFERRED"
+)
+
+// tableConstraints reads constraints of given types which reference only the listed columns
+func tableConstraints(ctx context.Context, db Querier, table PgTable, columns []string, types []ConstraintType) ([]PgConstraint, error) {
+	if len(types) == 0 {
+		return []PgConstraint{}, nil
+	}
+
+	contypes := make([]string, len(types))
+	for i, t := range types {
+		contypes[i] = string(t)
+	}
+
+	rows, err := db.QueryContext(ctx, `SELECT con.conname,
+								  con.contype,
+								  pg_get_constraintdef(con.oid),
+								  COALESCE(rn.nspname, ''),
+								  COALESCE(rc.relname, ''),
+								  COALESCE(rcon.contype::text, '')
+							 FROM pg_constraint con
+							 JOIN pg_class c ON con.conrelid = c.oid
+							 JOIN pg_namespace n ON c.relnamespace = n.oid
+							 LEFT JOIN pg_class rc ON con.confrelid = rc.oid
+							 LEFT JOIN pg_namespace rn ON rc.relnamespace = rn.oid
+							 LEFT JOIN pg_constraint rcon ON con.contype = 'f'
+							       AND rcon.conrelid = con.confrelid AND rcon.conindid = con.conindid
+							       AND rcon.contype IN ('p', 'u')
+							WHERE n.nspname = $1 AND c.relname = $2
+							  AND con.contype::text = ANY($3)
+							  AND NOT EXISTS (SELECT 1
+												FROM pg_attribute a
+											   WHERE a.attrelid = c.oid
+												 AND a.attnum = ANY(con.conkey)
+												 AND NOT a.attname = ANY($4))
+							ORDER BY con.conname`,
+		table.Schema, table.Name, pq.Array(contypes), pq.Array(columns))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	constraints := make([]PgConstraint, 0)
+	for rows.Next() {
+		var con PgConstraint
+		err = rows.Scan(&con.Name, &con.Type, &con.Definition, &con.Reference.Schema, &con.Reference.Name,
+			&con.ReferencedKey)
+		if err != nil {
+			return nil, err
+		}
+		constraints = append(constraints, con)
+	}
+	return constraints, rows.Err()
+}
+
+func columnNames(columns []PgTableColumn) []string {
+	names := make([]string, len(columns))
+	for i, col := range columns {
+		names[i] = col.Name
+	}
+	return names
+}
+
+// PublicationTableConstraints reads constraints of the publisher's table limited to published columns
+func PublicationTableConstraints(ctx context.Context, db Querier, table PgTableDetail, types []ConstraintType) ([]PgConstraint, error) {
+	return tableConstraints(ctx, db, table.PgTable, columnNames(table.Columns), types)
+}
+
+// ForeignKeyDefinition adjusts foreign key definition to be created as deferrable and/or not valid
+func ForeignKeyDefinition(def string, notValid, deferrable bool) string {
+	if strings.HasSuffix(def, notValidClause) {
+		def = strings.TrimSuffix(def, notValidClause)
+		notValid = true
+	}
+	if deferrable && !strings.Contains(def, deferrableClause) {
+		def += deferredClause
+	}
+	if notValid {
+		def += notValidClause
+	}
+	return def
+}
+
+func CreateSubscriptionConstraint(ctx context.Context, db Querier, table PgTable, con PgConstraint) error {
+	sql := fmt.Sprintf(`ALTER TABLE %s.%s ADD CONSTRAINT %s %s`,
+		pq.QuoteIdentifier(table.Schema), pq.QuoteIdentifier(table.Name),
+		pq.QuoteIdentifier(con.Name), con.Definition)
+	_, err := db.ExecContext(ctx, sql)
+	return err
+}
+
+// CheckSubscriptionConstraints returns expected constraints missing on the subscriber,
+// constraints with the same name and different definition are reported as ErrWrongAttributes.
+// A table has a single primary key, it's matched regardless of its name.
+func CheckSubscriptionConstraints(ctx context.Context, db Querier, table PgTable, expected []PgConstraint) ([]PgConstraint, error) {
+	if len(expected) == 0 {
+		return []PgConstraint{}, nil
+	}
+
+	types := make([]ConstraintType, 0, 4)
+	seen := map[ConstraintType]bool{}
+	for _, con := range expected {
+		if !seen[con.Type] {
+			seen[con.Type] = true
+			types = append(types, con.Type)
+		}
+	}
+
+	// constraints on the subscriber can reference any column
+	subscriptionTable, err := tableColumns(ctx, db, table, false)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := tableConstraints(ctx, db, table, columnNames(subscriptionTable.Columns), types)
+	if err != nil {
+		return nil, err
+	}
+
+	byName := make(map[string]PgConstraint, len(existing))
+	var primaryKey *PgConstraint
+	for _, con := range existing {
+		byName[con.Name] = con
+		if con.Type == PrimaryKeyConstraint {
+			primaryKey = &con
+		}
+	}
+
+	missing := make([]PgConstraint, 0)
+	for _, con := range expected {
+		found, ok := byName[con.Name]
+		if !ok && con.Type == PrimaryKeyConstraint && primaryKey != nil {
+			found, ok = *primaryKey, true
+		}
+		if !ok {
+			missing = append(missing, con)
+			continue
+		}
+		if found.Type != con.Type || found.Definition != con.Definition {
+			return nil, ErrWrongAttributes
+		}
+	}
+	return missing, nil
+}
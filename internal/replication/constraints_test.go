@@ -0,0 +1,29 @@
+package replication
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ForeignKeyDefinition", func() {
+	const def = "FOREIGN KEY (city_id) REFERENCES published_data.cities(id)"
+
+	It("should keep definition without options", func() {
+		Expect(ForeignKeyDefinition(def, false, false)).To(Equal(def))
+	})
+
+	It("should add deferrable and not valid clauses in pg_get_constraintdef order", func() {
+		Expect(ForeignKeyDefinition(def, true, true)).
+			To(Equal(def + " DEFERRABLE INITIALLY DEFERRED NOT VALID"))
+	})
+
+	It("should keep publisher's not valid clause last", func() {
+		Expect(ForeignKeyDefinition(def+" NOT VALID", false, true)).
+			To(Equal(def + " DEFERRABLE INITIALLY DEFERRED NOT VALID"))
+	})
+
+	It("should not add deferrable twice", func() {
+		Expect(ForeignKeyDefinition(def+" DEFERRABLE", false, true)).
+			To(Equal(def + " DEFERRABLE"))
+	})
+})
@@ -21,4 +21,32 @@ type DatabaseCredentials struct {
 
 	// Password of the admin account
 	AdminPassword string `mapstructure:"db.admin_password"`
+
+	// Username of the publisher's account with REPLICATION the subscription connects as
+	ReplicationUser string `mapstructure:"db.replication_user"`
+
+	// Password of the replication account
+	ReplicationPassword string `mapstructure:"db.replication_password"`
+}
+
+// Admin returns credentials of the admin account used for catalog changes,
+// the regular user is used when the admin account isn't set
+func (c DatabaseCredentials) Admin() DatabaseCredentials {
+	if c.AdminUser == "" {
+		return c
+	}
+	admin := c
+	admin.User, admin.Password = c.AdminUser, c.AdminPassword
+	return admin
+}
+
+// Replication returns credentials of the account the subscription connects as,
+// the regular user is used when the replication account isn't set
+func (c DatabaseCredentials) Replication() DatabaseCredentials {
+	if c.ReplicationUser == "" {
+		return c
+	}
+	repl := c
+	repl.User, repl.Password = c.ReplicationUser, c.ReplicationPassword
+	return repl
 }
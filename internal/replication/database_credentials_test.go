@@ -56,4 +56,35 @@ var _ = Describe("DatabaseCredentials struct", func() {
 			Expect(output.DatabaseName).To(Equal("db-name"))
 		})
 	})
+
+	Context("Accounts", func() {
+		credentials := DatabaseCredentials{
+			Host:                "db-hostname",
+			User:                "db-user",
+			Password:            "db-password",
+			AdminUser:           "db-admin-user",
+			AdminPassword:       "db-admin-password",
+			ReplicationUser:     "db-replication-user",
+			ReplicationPassword: "db-replication-password",
+		}
+
+		It("should use the admin account", func() {
+			admin := credentials.Admin()
+			Expect(admin.User).To(Equal("db-admin-user"))
+			Expect(admin.Password).To(Equal("db-admin-password"))
+			Expect(admin.Host).To(Equal("db-hostname"))
+		})
+
+		It("should use the replication account", func() {
+			repl := credentials.Replication()
+			Expect(repl.User).To(Equal("db-replication-user"))
+			Expect(repl.Password).To(Equal("db-replication-password"))
+		})
+
+		It("should fall back to the regular account", func() {
+			regular := DatabaseCredentials{User: "db-user", Password: "db-password"}
+			Expect(regular.Admin()).To(Equal(regular))
+			Expect(regular.Replication()).To(Equal(regular))
+		})
+	})
 })
@@ -0,0 +1,89 @@
+package replication
+
+import (
+	"context"
+)
+
+type ObjectKind string
+
+var (
+	FunctionObject ObjectKind = "f"
+	RelationObject ObjectKind = "r"
+	TypeObject     ObjectKind = "t"
+)
+
+// PgObject is a database object referenced by an expression,
+// Name is rendered by regprocedure, regclass or regtype
+type PgObject struct {
+	Kind ObjectKind
+	Name string
+}
+
+// PublicationDefaultDependencies reads functions, sequences and types
+// used by default expressions of the table's columns, grouped by column name
+func PublicationDefaultDependencies(ctx context.Context, db Querier, table PgTable) (map[string][]PgObject, error) {
+	rows, err := db.QueryContext(ctx, `SELECT a.attname,
+								  CASE d.refclassid
+									  WHEN 'pg_proc'::regclass THEN 'f'
+									  WHEN 'pg_class'::regclass THEN 'r'
+									  ELSE 't'
+								  END,
+								  CASE d.refclassid
+									  WHEN 'pg_proc'::regclass THEN d.refobjid::regprocedure::text
+									  WHEN 'pg_class'::regclass THEN d.refobjid::regclass::text
+									  ELSE d.refobjid::regtype::text
+								  END
+							 FROM pg_attrdef ad
+							 JOIN pg_attribute a ON a.attrelid = ad.adrelid AND a.attnum = ad.adnum
+							 JOIN pg_class c ON a.attrelid = c.oid
+							 JOIN pg_namespace n ON c.relnamespace = n.oid
+							 JOIN pg_depend d ON d.classid = 'pg_attrdef'::regclass AND d.objid = ad.oid
+							WHERE n.nspname = $1 AND c.relname = $2
+							  AND a.attgenerated = ''
+							  AND (d.refclassid IN ('pg_proc'::regclass, 'pg_type'::regclass)
+								   OR (d.refclassid = 'pg_class'::regclass AND d.refobjid <> ad.adrelid))
+							ORDER BY a.attnum`,
+		table.Schema, table.Name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deps := make(map[string][]PgObject)
+	for rows.Next() {
+		var (
+			column string
+			obj    PgObject
+		)
+		if err = rows.Scan(&column, &obj.Kind, &obj.Name); err != nil {
+			return nil, err
+		}
+		deps[column] = append(deps[column], obj)
+	}
+	return deps, rows.Err()
+}
+
+// CheckSubscriptionObjects returns objects which can't be resolved on the subscriber
+func CheckSubscriptionObjects(ctx context.Context, db Querier, objects []PgObject) ([]PgObject, error) {
+	missing := make([]PgObject, 0)
+	for _, obj := range objects {
+		var query string
+		switch obj.Kind {
+		case FunctionObject:
+			query = `SELECT to_regprocedure($1) IS NOT NULL`
+		case RelationObject:
+			query = `SELECT to_regclass($1) IS NOT NULL`
+		default:
+			query = `SELECT to_regtype($1) IS NOT NULL`
+		}
+
+		var exists bool
+		if err := db.QueryRowContext(ctx, query, obj.Name).Scan(&exists); err != nil {
+			return nil, err
+		}
+		if !exists {
+			missing = append(missing, obj)
+		}
+	}
+	return missing, nil
+}
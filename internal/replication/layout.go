@@ -0,0 +1,228 @@
+package replication
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+var (
+	RangePartitioning = "RANGE"
+	ListPartitioning  = "LIST"
+	HashPartitioning  = "HASH"
+)
+
+var (
+	DayInterval   = "day"
+	WeekInterval  = "week"
+	MonthInterval = "month"
+	YearInterval  = "year"
+)
+
+const defaultPartitionSuffix = "_default"
+
+// longest suffix of a list partition, the table's name is shortened to fit it
+const maxListPartitionName = MaxIdentifierLength - nameHashLength - 2
+
+type ListPartition struct {
+	Name   string
+	Values []string
+}
+
+// PartitionLayout is the subscriber's partitioning which differs from the publisher's table.
+// Range partitions are rolled by time intervals, the operator creates partitions ahead
+// and drops partitions older than retention.
+type PartitionLayout struct {
+	Strategy string
+	Key      string
+	// range partitioning
+	Interval  string
+	Premake   int
+	Retention int
+	// list partitioning
+	Lists []ListPartition
+	// hash partitioning
+	Modulus int
+}
+
+// KeyColumns parses the partition key into column names, unquoted
+// identifiers are folded to lower case as PostgreSQL does
+func (l PartitionLayout) KeyColumns() ([]string, error) {
+	// split on commas outside of quoted identifiers
+	parts := make([]string, 0, 1)
+	quoted := false
+	start := 0
+	for i, r := range l.Key {
+		if r == '"' {
+			quoted = !quoted
+		} else if r == ',' && !quoted {
+			parts = append(parts, l.Key[start:i])
+			start = i + 1
+		}
+	}
+	parts = append(parts, l.Key[start:])
+
+	columns := make([]string, len(parts))
+	for i, part := range parts {
+		part = strings.TrimSpace(part)
+		switch {
+		case len(part) > 2 && strings.HasPrefix(part, `"`) && strings.HasSuffix(part, `"`) &&
+			!strings.Contains(strings.ReplaceAll(part[1:len(part)-1], `""`, ""), `"`):
+			columns[i] = strings.ReplaceAll(part[1:len(part)-1], `""`, `"`)
+		case part != "" && !strings.ContainsAny(part, "\" \t\n"):
+			columns[i] = strings.ToLower(part)
+		default:
+			return nil, fmt.Errorf("invalid column %q in partition key %q", part, l.Key)
+		}
+	}
+	return columns, nil
+}
+
+// Validate checks the layout can be created
+func (l PartitionLayout) Validate() error {
+	columns, err := l.KeyColumns()
+	if err != nil {
+		return err
+	}
+	switch {
+	case l.Strategy == RangePartitioning && len(columns) != 1:
+		return fmt.Errorf("range partitioning by %q requires a single column key", l.Key)
+	case l.Strategy == HashPartitioning && l.Modulus < 1:
+		return fmt.Errorf("hash partitioning requires modulus of at least 1, got %d", l.Modulus)
+	}
+	for _, list := range l.Lists {
+		if len(list.Name) > maxListPartitionName {
+			return fmt.Errorf("list partition name %q is longer than %d bytes", list.Name, maxListPartitionName)
+		}
+	}
+	return nil
+}
+
+// PartitionKey renders the partition key as pg_get_partkeydef does,
+// the columns are quoted by the database so that the key matches the catalog
+func (l PartitionLayout) PartitionKey(ctx context.Context, db Querier) (string, error) {
+	columns, err := l.KeyColumns()
+	if err != nil {
+		return "", err
+	}
+
+	var key string
+	err = db.QueryRowContext(ctx, `SELECT string_agg(quote_ident(k.name), ', ' ORDER BY k.ord)
+									 FROM unnest($1::text[]) WITH ORDINALITY k(name, ord)`,
+		pq.Array(columns)).Scan(&key)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s (%s)", l.Strategy, key), nil
+}
+
+func (l PartitionLayout) truncate(t time.Time) time.Time {
+	t = t.UTC()
+	switch l.Interval {
+	case WeekInterval:
+		t = t.AddDate(0, 0, -((int(t.Weekday()) + 6) % 7))
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	case MonthInterval:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	case YearInterval:
+		return time.Date(t.Year(), 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func (l PartitionLayout) add(t time.Time, n int) time.Time {
+	switch l.Interval {
+	case WeekInterval:
+		return t.AddDate(0, 0, 7*n)
+	case MonthInterval:
+		return t.AddDate(0, n, 0)
+	case YearInterval:
+		return t.AddDate(n, 0, 0)
+	}
+	return t.AddDate(0, 0, n)
+}
+
+func (l PartitionLayout) suffixFormat() string {
+	switch l.Interval {
+	case MonthInterval:
+		return "200601"
+	case YearInterval:
+		return "2006"
+	}
+	return "20060102"
+}
+
+// rangePrefix is shared by the table's range partitions, the date suffixes have the same length
+func (l PartitionLayout) rangePrefix(table PgTable) string {
+	return truncateForSuffix(table.Name, len("_p")+len(l.suffixFormat())) + "_p"
+}
+
+// Partitions returns partitions the subscriber's table should have at the given time,
+// a default partition catches rows outside of the other partitions so that the apply
+// worker never fails on them. Names of a long table are shortened to keep the suffixes.
+func (l PartitionLayout) Partitions(table PgTable, now time.Time) []PgPartition {
+	partitions := make([]PgPartition, 0)
+	partition := func(name, bound string) PgPartition {
+		return PgPartition{
+			PgTable: PgTable{Schema: table.Schema, Name: name},
+			Parent:  table,
+			Bound:   bound,
+		}
+	}
+
+	switch l.Strategy {
+	case RangePartitioning:
+		start := l.truncate(now)
+		for i := -l.Retention; i <= l.Premake; i++ {
+			from := l.add(start, i)
+			to := l.add(start, i+1)
+			partitions = append(partitions, partition(
+				l.rangePrefix(table)+from.Format(l.suffixFormat()),
+				fmt.Sprintf("FOR VALUES FROM ('%s') TO ('%s')", from.Format(time.DateOnly), to.Format(time.DateOnly))))
+		}
+
+	case ListPartitioning:
+		for _, list := range l.Lists {
+			partitions = append(partitions, partition(
+				suffixedIdentifier(table.Name, "_"+list.Name),
+				fmt.Sprintf("FOR VALUES IN (%s)", strings.Join(quoteLiterals(list.Values), ", "))))
+		}
+
+	case HashPartitioning:
+		for i := 0; i < l.Modulus; i++ {
+			partitions = append(partitions, partition(
+				suffixedIdentifier(table.Name, fmt.Sprintf("_h%d", i)),
+				fmt.Sprintf("FOR VALUES WITH (MODULUS %d, REMAINDER %d)", l.Modulus, i)))
+		}
+		return partitions
+	}
+
+	return append(partitions, partition(suffixedIdentifier(table.Name, defaultPartitionSuffix), "DEFAULT"))
+}
+
+// ExpiredPartitions returns range partitions older than retention
+func (l PartitionLayout) ExpiredPartitions(table PgTable, existing []PgPartition, now time.Time) []PgPartition {
+	expired := make([]PgPartition, 0)
+	if l.Strategy != RangePartitioning || l.Retention <= 0 {
+		return expired
+	}
+
+	cutoff := l.add(l.truncate(now), -l.Retention)
+	prefix := l.rangePrefix(table)
+	for _, p := range existing {
+		if p.Parent != table || !strings.HasPrefix(p.Name, prefix) {
+			continue
+		}
+		from, err := time.Parse(l.suffixFormat(), strings.TrimPrefix(p.Name, prefix))
+		if err != nil { // not managed by the operator
+			continue
+		}
+		if from.Before(cutoff) {
+			expired = append(expired, p)
+		}
+	}
+	return expired
+}
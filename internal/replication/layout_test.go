@@ -0,0 +1,113 @@
+package replication
+
+import (
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("PartitionLayout", func() {
+	table := PgTable{Schema: "data", Name: "events"}
+	now := time.Date(2024, time.March, 14, 15, 9, 26, 0, time.UTC)
+
+	names := func(partitions []PgPartition) []string {
+		result := make([]string, len(partitions))
+		for i, p := range partitions {
+			result[i] = p.Name
+		}
+		return result
+	}
+
+	It("should parse partition key columns", func() {
+		layout := PartitionLayout{Strategy: ListPartitioning, Key: `Kind, "Tenant ""A""",region`}
+		Expect(layout.KeyColumns()).To(Equal([]string{"kind", `Tenant "A"`, "region"}))
+
+		for _, key := range []string{"", "kind,", `"kind`, `"kind"x`, "created at", `""`} {
+			layout.Key = key
+			_, err := layout.KeyColumns()
+			Expect(err).To(HaveOccurred(), key)
+		}
+	})
+
+	It("should validate the layout", func() {
+		Expect(PartitionLayout{Strategy: RangePartitioning, Key: "created_at"}.Validate()).To(Succeed())
+		Expect(PartitionLayout{Strategy: RangePartitioning, Key: "created_at, id"}.Validate()).NotTo(Succeed())
+		Expect(PartitionLayout{Strategy: HashPartitioning, Key: "id"}.Validate()).NotTo(Succeed())
+		Expect(PartitionLayout{Strategy: HashPartitioning, Key: "id", Modulus: 4}.Validate()).To(Succeed())
+	})
+
+	It("should roll monthly range partitions", func() {
+		layout := PartitionLayout{Strategy: RangePartitioning, Key: "created_at",
+			Interval: MonthInterval, Premake: 1, Retention: 1}
+
+		partitions := layout.Partitions(table, now)
+		Expect(names(partitions)).To(Equal([]string{
+			"events_p202402", "events_p202403", "events_p202404", "events_default"}))
+		Expect(partitions[1].Bound).To(Equal("FOR VALUES FROM ('2024-03-01') TO ('2024-04-01')"))
+		Expect(partitions[1].Parent).To(Equal(table))
+		Expect(partitions[3].Bound).To(Equal("DEFAULT"))
+	})
+
+	It("should start weekly partitions on monday", func() {
+		layout := PartitionLayout{Strategy: RangePartitioning, Key: "created_at", Interval: WeekInterval}
+
+		partitions := layout.Partitions(table, now)
+		Expect(partitions[0].Name).To(Equal("events_p20240311"))
+		Expect(partitions[0].Bound).To(Equal("FOR VALUES FROM ('2024-03-11') TO ('2024-03-18')"))
+	})
+
+	It("should create list and hash partitions", func() {
+		list := PartitionLayout{Strategy: ListPartitioning, Key: "kind",
+			Lists: []ListPartition{{Name: "web", Values: []string{"http", "https"}}}}
+		Expect(list.Partitions(table, now)[0].Bound).To(Equal("FOR VALUES IN ('http', 'https')"))
+		Expect(names(list.Partitions(table, now))).To(Equal([]string{"events_web", "events_default"}))
+
+		hash := PartitionLayout{Strategy: HashPartitioning, Key: "id", Modulus: 2}
+		Expect(names(hash.Partitions(table, now))).To(Equal([]string{"events_h0", "events_h1"}))
+		Expect(hash.Partitions(table, now)[1].Bound).To(Equal("FOR VALUES WITH (MODULUS 2, REMAINDER 1)"))
+	})
+
+	It("should keep suffixes of partitions of a long table", func() {
+		long := PgTable{Schema: "data", Name: strings.Repeat("e", 60)}
+
+		for _, layout := range []PartitionLayout{
+			{Strategy: RangePartitioning, Key: "created_at", Interval: DayInterval, Premake: 1, Retention: 1},
+			{Strategy: ListPartitioning, Key: "kind", Lists: []ListPartition{{Name: "web", Values: []string{"http"}}}},
+			{Strategy: HashPartitioning, Key: "id", Modulus: 2},
+		} {
+			for _, p := range layout.Partitions(long, now) {
+				Expect(len(p.Name)).To(BeNumerically("<=", MaxIdentifierLength), p.Name)
+				Expect(p.Name).To(HavePrefix(strings.Repeat("e", 40)))
+			}
+		}
+
+		daily := PartitionLayout{Strategy: RangePartitioning, Key: "created_at", Premake: 1, Retention: 1}
+		partitions := daily.Partitions(long, now)
+		Expect(partitions[0].Name).To(HaveSuffix("_p20240313"))
+		Expect(partitions[3].Name).To(HaveSuffix(defaultPartitionSuffix))
+		Expect(names(daily.ExpiredPartitions(long, partitions, now.AddDate(0, 0, 1)))).
+			To(Equal([]string{partitions[0].Name}))
+
+		list := PartitionLayout{Strategy: ListPartitioning, Key: "kind",
+			Lists: []ListPartition{{Name: strings.Repeat("w", 60)}}}
+		Expect(list.Validate()).NotTo(Succeed())
+	})
+
+	It("should expire only operator's range partitions older than retention", func() {
+		layout := PartitionLayout{Strategy: RangePartitioning, Key: "created_at", Retention: 2}
+		partition := func(name string) PgPartition {
+			return PgPartition{PgTable: PgTable{Schema: "data", Name: name}, Parent: table}
+		}
+		existing := []PgPartition{
+			partition("events_p20240311"),
+			partition("events_p20240312"),
+			partition("events_p20240314"),
+			partition("events_default"),
+			partition("events_archive"),
+		}
+
+		Expect(names(layout.ExpiredPartitions(table, existing, now))).To(Equal([]string{"events_p20240311"}))
+	})
+})
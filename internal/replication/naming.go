@@ -0,0 +1,73 @@
+package replication
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"unicode/utf8"
+)
+
+// MaxIdentifierLength is the length of the longest identifier PostgreSQL keeps (NAMEDATALEN - 1)
+const MaxIdentifierLength = 63
+
+const nameHashLength = 8
+
+var (
+	SuffixNaming       = "suffix"
+	PrefixNaming       = "prefix"
+	SchemaNaming       = "schema"
+	HashedSuffixNaming = "hashedsuffix"
+)
+
+func nameHash(name string) string {
+	hash := sha256.Sum256([]byte(name))
+	return hex.EncodeToString(hash[:])[:nameHashLength]
+}
+
+// TruncateIdentifier shortens the name to fit into PostgreSQL identifier,
+// truncated names end with a hash of the full name so they don't collide
+func TruncateIdentifier(name string) string {
+	return truncateForSuffix(name, 0)
+}
+
+// truncateForSuffix shortens the name so that a suffix of the length fits after it
+// into PostgreSQL identifier, names derived from the same name share the shortened name
+func truncateForSuffix(name string, suffixLength int) string {
+	if len(name)+suffixLength <= MaxIdentifierLength {
+		return name
+	}
+
+	cut := max(MaxIdentifierLength-suffixLength-nameHashLength-1, 0)
+	for cut > 0 && !utf8.RuneStart(name[cut]) { // don't split multi-byte characters
+		cut--
+	}
+	return name[:cut] + "_" + nameHash(name)
+}
+
+// suffixedIdentifier appends the suffix to the name, the name is shortened
+// instead of the suffix so that PostgreSQL doesn't truncate the suffix
+func suffixedIdentifier(name, suffix string) string {
+	return truncateForSuffix(name, len(suffix)) + suffix
+}
+
+// TableNaming names tables of a previous publication version on the subscriber
+type TableNaming struct {
+	Strategy string
+	// schema of the renamed tables with SchemaNaming
+	ArchiveSchema string
+}
+
+// RenamedTable returns the name of the table kept for the publication version
+func (n TableNaming) RenamedTable(table PgTable, version string) PgTable {
+	switch n.Strategy {
+	case PrefixNaming:
+		return PgTable{Schema: table.Schema, Name: TruncateIdentifier(version + "_" + table.Name)}
+	case SchemaNaming:
+		return PgTable{
+			Schema: n.ArchiveSchema,
+			Name:   TruncateIdentifier(table.Schema + "_" + table.Name + "_" + version),
+		}
+	case HashedSuffixNaming:
+		return PgTable{Schema: table.Schema, Name: TruncateIdentifier(table.Name + "_" + nameHash(version))}
+	}
+	return PgTable{Schema: table.Schema, Name: TruncateIdentifier(table.Name + "_" + version)}
+}
@@ -0,0 +1,41 @@
+package replication
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("TruncateIdentifier", func() {
+	It("should keep short names", func() {
+		Expect(TruncateIdentifier("cities_publication_v1")).To(Equal("cities_publication_v1"))
+	})
+
+	It("should shorten long names without collisions", func() {
+		long := strings.Repeat("a", 60) + "_publication_v1"
+		other := strings.Repeat("a", 60) + "_publication_v2"
+		Expect(TruncateIdentifier(long)).To(HaveLen(MaxIdentifierLength))
+		Expect(TruncateIdentifier(long)).NotTo(Equal(TruncateIdentifier(other)))
+	})
+
+	It("should not split multi-byte characters", func() {
+		name := strings.Repeat("č", 40)
+		Expect(len(TruncateIdentifier(name))).To(BeNumerically("<=", MaxIdentifierLength))
+		Expect(TruncateIdentifier(name)).To(HavePrefix(strings.Repeat("č", 27) + "_"))
+	})
+})
+
+var _ = Describe("TableNaming", func() {
+	table := PgTable{Schema: "data", Name: "cities"}
+
+	It("should rename tables by the strategy", func() {
+		Expect(TableNaming{}.RenamedTable(table, "v1")).To(Equal(PgTable{Schema: "data", Name: "cities_v1"}))
+		Expect(TableNaming{Strategy: PrefixNaming}.RenamedTable(table, "v1")).
+			To(Equal(PgTable{Schema: "data", Name: "v1_cities"}))
+		Expect(TableNaming{Strategy: SchemaNaming, ArchiveSchema: "archive"}.RenamedTable(table, "v1")).
+			To(Equal(PgTable{Schema: "archive", Name: "data_cities_v1"}))
+		Expect(TableNaming{Strategy: HashedSuffixNaming}.RenamedTable(table, "v1").Name).
+			To(MatchRegexp(`^cities_[0-9a-f]{8}$`))
+	})
+})
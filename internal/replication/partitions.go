@@ -0,0 +1,196 @@
+package replication
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+type PgPartition struct {
+	PgTable
+	Parent PgTable
+	// partition bound rendered by pg_get_expr
+	Bound string
+	// partition key of a sub-partitioned partition, empty for leaf partitions
+	PartitionKey string
+}
+
+// PublicationTablePartitions reads partition key and partitions of the published table.
+// Partitioned table published via its root is replicated into a regular table,
+// nothing is returned for it as for tables which are not partitioned.
+func PublicationTablePartitions(ctx context.Context, db Querier, pubname string, table PgTable) (string, []PgPartition, error) {
+	row := db.QueryRowContext(ctx, `SELECT COALESCE(pg_get_partkeydef(c.oid), '')
+						  FROM pg_class c
+						  JOIN pg_namespace n ON c.relnamespace = n.oid
+						  JOIN pg_publication p ON p.pubname = $3
+						 WHERE n.nspname = $1 AND c.relname = $2
+						   AND c.relkind = 'p' AND NOT p.pubviaroot`,
+		table.Schema, table.Name, pubname)
+	var partitionKey string
+	err := row.Scan(&partitionKey)
+	if err == sql.ErrNoRows {
+		return "", []PgPartition{}, nil
+	} else if err != nil {
+		return "", nil, err
+	}
+
+	partitions, err := tablePartitions(ctx, db, table)
+	return partitionKey, partitions, err
+}
+
+// tablePartitions reads all partitions of the table, parents are listed before their partitions
+func tablePartitions(ctx context.Context, db Querier, table PgTable) ([]PgPartition, error) {
+	rows, err := db.QueryContext(ctx, `SELECT n.nspname,
+								  c.relname,
+								  pn.nspname,
+								  pc.relname,
+								  pg_get_expr(c.relpartbound, c.oid),
+								  COALESCE(pg_get_partkeydef(c.oid), '')
+							 FROM pg_partition_tree(to_regclass($1)) pt
+							 JOIN pg_class c ON pt.relid = c.oid
+							 JOIN pg_namespace n ON c.relnamespace = n.oid
+							 JOIN pg_class pc ON pt.parentrelid = pc.oid
+							 JOIN pg_namespace pn ON pc.relnamespace = pn.oid
+							WHERE pt.level > 0
+							ORDER BY pt.level, n.nspname, c.relname`,
+		pq.QuoteIdentifier(table.Schema)+"."+pq.QuoteIdentifier(table.Name))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	partitions := make([]PgPartition, 0)
+	for rows.Next() {
+		var p PgPartition
+		err = rows.Scan(&p.Schema, &p.Name, &p.Parent.Schema, &p.Parent.Name, &p.Bound, &p.PartitionKey)
+		if err != nil {
+			return nil, err
+		}
+		partitions = append(partitions, p)
+	}
+	return partitions, rows.Err()
+}
+
+func tablePartitionKey(ctx context.Context, db Querier, table PgTable) (string, error) {
+	row := db.QueryRowContext(ctx, `SELECT COALESCE(pg_get_partkeydef(c.oid), '')
+						  FROM pg_class c
+						  JOIN pg_namespace n ON c.relnamespace = n.oid
+						 WHERE n.nspname = $1 AND c.relname = $2`, table.Schema, table.Name)
+	var partitionKey string
+	err := row.Scan(&partitionKey)
+	return partitionKey, err
+}
+
+func createPartitionSQL(p PgPartition) string {
+	sql := fmt.Sprintf(`CREATE TABLE %s.%s PARTITION OF %s.%s %s`,
+		pq.QuoteIdentifier(p.Schema), pq.QuoteIdentifier(p.Name),
+		pq.QuoteIdentifier(p.Parent.Schema), pq.QuoteIdentifier(p.Parent.Name), p.Bound)
+	if p.PartitionKey != "" {
+		sql += " PARTITION BY " + p.PartitionKey
+	}
+	return sql
+}
+
+// CreateSubscriptionPartition creates the partition, rows of its bound already stored
+// in the parent's default partition are moved into the new partition
+func CreateSubscriptionPartition(ctx context.Context, db Querier, p PgPartition) error {
+	return InTransaction(ctx, db, func(tx Querier) error {
+		if _, err := tx.ExecContext(ctx, "SAVEPOINT create_partition"); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, createPartitionSQL(p))
+		var pqerr *pq.Error
+		// default partition's constraint would be violated by some row
+		if !errors.As(err, &pqerr) || pqerr.Code != "23514" || p.Bound == "DEFAULT" {
+			return err
+		}
+		if _, err = tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT create_partition"); err != nil {
+			return err
+		}
+		return moveDefaultPartitionRows(ctx, tx, p)
+	})
+}
+
+// moveDefaultPartitionRows creates the partition while the parent's default
+// partition is detached and moves the default partition's rows of its bound
+func moveDefaultPartitionRows(ctx context.Context, db Querier, p PgPartition) error {
+	parent := pq.QuoteIdentifier(p.Parent.Schema) + "." + pq.QuoteIdentifier(p.Parent.Name)
+	var defaultPartition PgTable
+	err := db.QueryRowContext(ctx, `SELECT n.nspname, c.relname
+									  FROM pg_partitioned_table pt
+									  JOIN pg_class c ON pt.partdefid = c.oid
+									  JOIN pg_namespace n ON c.relnamespace = n.oid
+									 WHERE pt.partrelid = to_regclass($1)`, parent).
+		Scan(&defaultPartition.Schema, &defaultPartition.Name)
+	if err != nil {
+		return err
+	}
+	defaultName := pq.QuoteIdentifier(defaultPartition.Schema) + "." + pq.QuoteIdentifier(defaultPartition.Name)
+
+	if _, err = db.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s DETACH PARTITION %s", parent, defaultName)); err != nil {
+		return err
+	}
+	if _, err = db.ExecContext(ctx, createPartitionSQL(p)); err != nil {
+		return err
+	}
+
+	var constraint string
+	err = db.QueryRowContext(ctx, `SELECT pg_get_partition_constraintdef(to_regclass($1))`,
+		pq.QuoteIdentifier(p.Schema)+"."+pq.QuoteIdentifier(p.Name)).Scan(&constraint)
+	if err != nil {
+		return err
+	}
+	_, err = db.ExecContext(ctx, fmt.Sprintf(`WITH moved AS (DELETE FROM %s WHERE %s RETURNING *)
+											  INSERT INTO %s SELECT * FROM moved`, defaultName, constraint, parent))
+	if err != nil {
+		return err
+	}
+
+	_, err = db.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s ATTACH PARTITION %s DEFAULT", parent, defaultName))
+	return err
+}
+
+func SubscriptionTablePartitions(ctx context.Context, db Querier, table PgTable) ([]PgPartition, error) {
+	return tablePartitions(ctx, db, table)
+}
+
+func DropSubscriptionPartition(ctx context.Context, db Querier, p PgPartition) error {
+	sql := fmt.Sprintf(`DROP TABLE %s.%s`, pq.QuoteIdentifier(p.Schema), pq.QuoteIdentifier(p.Name))
+	_, err := db.ExecContext(ctx, sql)
+	return err
+}
+
+// CheckSubscriptionPartitions returns partitions missing on the subscriber,
+// partitions with different bounds are reported as ErrWrongAttributes when compareBounds is set
+func CheckSubscriptionPartitions(ctx context.Context, db Querier, table PgTableDetail, compareBounds bool) ([]PgPartition, error) {
+	if len(table.Partitions) == 0 {
+		return []PgPartition{}, nil
+	}
+
+	existing, err := tablePartitions(ctx, db, table.PgTable)
+	if err != nil {
+		return nil, err
+	}
+
+	byName := make(map[PgTable]PgPartition, len(existing))
+	for _, p := range existing {
+		byName[p.PgTable] = p
+	}
+
+	missing := make([]PgPartition, 0)
+	for _, p := range table.Partitions {
+		found, ok := byName[p.PgTable]
+		if !ok {
+			missing = append(missing, p)
+			continue
+		}
+		if found.Parent != p.Parent ||
+			(compareBounds && (found.Bound != p.Bound || found.PartitionKey != p.PartitionKey)) {
+			return nil, ErrWrongAttributes
+		}
+	}
+	return missing, nil
+}
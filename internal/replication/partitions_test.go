@@ -0,0 +1,31 @@
+package replication
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("createPartitionSQL", func() {
+	parent := PgTable{Schema: "data", Name: "events"}
+
+	It("should create leaf partition", func() {
+		p := PgPartition{
+			PgTable: PgTable{Schema: "data", Name: "events_2024"},
+			Parent:  parent,
+			Bound:   "FOR VALUES FROM ('2024-01-01') TO ('2025-01-01')",
+		}
+		Expect(createPartitionSQL(p)).To(Equal(`CREATE TABLE "data"."events_2024" PARTITION OF "data"."events" ` +
+			`FOR VALUES FROM ('2024-01-01') TO ('2025-01-01')`))
+	})
+
+	It("should create sub-partitioned partition", func() {
+		p := PgPartition{
+			PgTable:      PgTable{Schema: "data", Name: "events_default"},
+			Parent:       parent,
+			Bound:        "DEFAULT",
+			PartitionKey: "LIST (kind)",
+		}
+		Expect(createPartitionSQL(p)).To(Equal(`CREATE TABLE "data"."events_default" PARTITION OF "data"."events" ` +
+			`DEFAULT PARTITION BY LIST (kind)`))
+	})
+})
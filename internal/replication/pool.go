@@ -0,0 +1,198 @@
+package replication
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// ConnectionManager shares connection pools between reconciliations
+// and replications using the same database credentials
+type ConnectionManager struct {
+	// open connections of a pool
+	MaxOpenConns int
+	// unused pools are closed after the timeout
+	IdleTimeout time.Duration
+	Timeouts    Timeouts
+
+	mu    sync.Mutex
+	pools map[string]*connectionPool
+	// credentials hash last used by the owner
+	owners map[string]string
+	// pools by their handle, including evicted pools still in use
+	acquired map[*sql.DB]*connectionPool
+}
+
+type connectionPool struct {
+	db       *sql.DB
+	owners   map[string]struct{}
+	lastUsed time.Time
+	// reconciliations using the pool, the pool isn't closed until they release it
+	active int
+	// evicted pool is closed once it's released
+	evicted bool
+}
+
+func NewConnectionManager(maxOpenConns int, idleTimeout time.Duration, timeouts Timeouts) *ConnectionManager {
+	return &ConnectionManager{
+		MaxOpenConns: maxOpenConns,
+		IdleTimeout:  idleTimeout,
+		Timeouts:     timeouts,
+		pools:        make(map[string]*connectionPool),
+		owners:       make(map[string]string),
+		acquired:     make(map[*sql.DB]*connectionPool),
+	}
+}
+
+func credentialsHash(credentials DatabaseCredentials) string {
+	sum := sha256.Sum256([]byte(CredentialsToConnectionString(credentials)))
+	return hex.EncodeToString(sum[:])
+}
+
+// Connect returns the pool for the credentials, the owner identifies the source
+// of the credentials, the pool of its previous credentials is closed
+// when nobody else uses it. The pool has to be released after its use.
+func (m *ConnectionManager) Connect(ctx context.Context, owner string,
+	credentials DatabaseCredentials) (*sql.DB, error) {
+	db, err := m.pool(owner, credentials)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = db.PingContext(ctx); err != nil {
+		m.Release(db)
+		m.Evict(owner)
+		return nil, err
+	}
+	return db, nil
+}
+
+// Release returns the pool acquired by Connect, an evicted pool is closed
+// once the last reconciliation using it releases it
+func (m *ConnectionManager) Release(db *sql.DB) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	pool, ok := m.acquired[db]
+	if !ok {
+		return
+	}
+	pool.active--
+	pool.lastUsed = time.Now()
+	if pool.evicted && pool.active == 0 {
+		delete(m.acquired, db)
+		pool.db.Close()
+	}
+}
+
+func (m *ConnectionManager) pool(owner string, credentials DatabaseCredentials) (*sql.DB, error) {
+	key := credentialsHash(credentials)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if previous, ok := m.owners[owner]; ok && previous != key {
+		m.release(owner, previous)
+	}
+	pool, ok := m.pools[key]
+	if !ok {
+		db, err := sql.Open("postgres", sessionConnectionString(credentials, m.Timeouts))
+		if err != nil {
+			return nil, err
+		}
+		db.SetMaxOpenConns(m.MaxOpenConns)
+		db.SetMaxIdleConns(m.MaxOpenConns)
+		if m.IdleTimeout > 0 {
+			db.SetConnMaxIdleTime(m.IdleTimeout)
+		}
+		pool = &connectionPool{db: db, owners: make(map[string]struct{})}
+		m.pools[key] = pool
+		m.acquired[db] = pool
+	}
+	pool.owners[owner] = struct{}{}
+	pool.lastUsed = time.Now()
+	pool.active++
+	m.owners[owner] = key
+	return pool.db, nil
+}
+
+// Evict closes the owner's pool when nobody else uses it
+func (m *ConnectionManager) Evict(owner string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if key, ok := m.owners[owner]; ok {
+		m.release(owner, key)
+	}
+}
+
+func (m *ConnectionManager) release(owner, key string) {
+	delete(m.owners, owner)
+	pool, ok := m.pools[key]
+	if !ok {
+		return
+	}
+	delete(pool.owners, owner)
+	if len(pool.owners) == 0 {
+		m.evict(key, pool)
+	}
+}
+
+// evict removes the pool so that it isn't shared anymore, it's closed
+// right away unless a reconciliation still uses it
+func (m *ConnectionManager) evict(key string, pool *connectionPool) {
+	delete(m.pools, key)
+	if pool.active > 0 {
+		pool.evicted = true
+		return
+	}
+	delete(m.acquired, pool.db)
+	pool.db.Close()
+}
+
+// closeIdle closes pools unused since the deadline, pools in use are never idle
+func (m *ConnectionManager) closeIdle(deadline time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for key, pool := range m.pools {
+		if pool.active == 0 && pool.lastUsed.Before(deadline) {
+			for owner := range pool.owners {
+				delete(m.owners, owner)
+			}
+			m.evict(key, pool)
+		}
+	}
+}
+
+// Close closes all pools
+func (m *ConnectionManager) Close() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, pool := range m.acquired {
+		pool.db.Close()
+	}
+	m.pools = make(map[string]*connectionPool)
+	m.owners = make(map[string]string)
+	m.acquired = make(map[*sql.DB]*connectionPool)
+}
+
+// Start closes idle pools until the context is done, it implements manager.Runnable
+func (m *ConnectionManager) Start(ctx context.Context) error {
+	if m.IdleTimeout <= 0 {
+		<-ctx.Done()
+		m.Close()
+		return nil
+	}
+
+	ticker := time.NewTicker(m.IdleTimeout / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			m.Close()
+			return nil
+		case now := <-ticker.C:
+			m.closeIdle(now.Add(-m.IdleTimeout))
+		}
+	}
+}
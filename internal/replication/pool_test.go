@@ -0,0 +1,105 @@
+package replication
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ConnectionManager", func() {
+	credentials := DatabaseCredentials{Host: "db", Port: "5432", User: "user", Password: "password", DatabaseName: "data"}
+	var connections *ConnectionManager
+
+	BeforeEach(func() {
+		connections = NewConnectionManager(2, time.Minute, Timeouts{})
+	})
+
+	AfterEach(func() {
+		connections.Close()
+	})
+
+	It("should share pools of the same credentials", func() {
+		first, err := connections.pool("ns/publisher", credentials)
+		Expect(err).NotTo(HaveOccurred())
+		second, err := connections.pool("ns/other", credentials)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(second).To(BeIdenticalTo(first))
+		Expect(first.Stats().MaxOpenConnections).To(Equal(2))
+	})
+
+	It("should evict the pool of rotated credentials", func() {
+		_, err := connections.pool("ns/publisher", credentials)
+		Expect(err).NotTo(HaveOccurred())
+
+		rotated := credentials
+		rotated.Password = "rotated"
+		_, err = connections.pool("ns/publisher", rotated)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(connections.pools).To(HaveLen(1))
+		Expect(connections.pools).To(HaveKey(credentialsHash(rotated)))
+	})
+
+	It("should keep pools used by other owners", func() {
+		_, err := connections.pool("ns/publisher", credentials)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = connections.pool("ns/other", credentials)
+		Expect(err).NotTo(HaveOccurred())
+
+		connections.Evict("ns/publisher")
+		Expect(connections.pools).To(HaveKey(credentialsHash(credentials)))
+		connections.Evict("ns/other")
+		Expect(connections.pools).To(BeEmpty())
+	})
+
+	It("should close idle pools", func() {
+		db, err := connections.pool("ns/publisher", credentials)
+		Expect(err).NotTo(HaveOccurred())
+		connections.Release(db)
+
+		connections.closeIdle(time.Now().Add(-time.Minute))
+		Expect(connections.pools).To(HaveLen(1))
+		connections.closeIdle(time.Now().Add(time.Second))
+		Expect(connections.pools).To(BeEmpty())
+		Expect(connections.owners).To(BeEmpty())
+		Expect(connections.acquired).To(BeEmpty())
+	})
+
+	It("should keep pools in use open", func() {
+		db, err := connections.pool("ns/publisher", credentials)
+		Expect(err).NotTo(HaveOccurred())
+
+		connections.closeIdle(time.Now().Add(time.Second))
+		Expect(connections.pools).To(HaveLen(1))
+
+		connections.Release(db)
+		connections.closeIdle(time.Now().Add(time.Second))
+		Expect(connections.pools).To(BeEmpty())
+	})
+
+	It("should close an evicted pool once it's released", func() {
+		db, err := connections.pool("ns/publisher", credentials)
+		Expect(err).NotTo(HaveOccurred())
+		other, err := connections.pool("ns/other", credentials)
+		Expect(err).NotTo(HaveOccurred())
+
+		connections.Evict("ns/publisher")
+		connections.Evict("ns/other")
+		Expect(connections.pools).To(BeEmpty())
+		Expect(connections.acquired).To(HaveKey(db))
+
+		connections.Release(db)
+		Expect(connections.acquired).To(HaveKey(db))
+		connections.Release(other)
+		Expect(connections.acquired).To(BeEmpty())
+		Expect(db.Ping()).To(MatchError("sql: database is closed"))
+	})
+
+	It("should set session timeouts", func() {
+		connStr := sessionConnectionString(credentials, Timeouts{Statement: time.Minute, Lock: 5 * time.Second})
+		Expect(connStr).To(HavePrefix(CredentialsToConnectionString(credentials) + "&"))
+		Expect(connStr).To(ContainSubstring("statement_timeout=60000"))
+		Expect(connStr).To(ContainSubstring("lock_timeout=5000"))
+		Expect(sessionConnectionString(credentials, Timeouts{})).To(Equal(CredentialsToConnectionString(credentials)))
+	})
+})
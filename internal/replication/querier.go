@@ -0,0 +1,34 @@
+package replication
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Querier runs statements on the database's pool or in a transaction,
+// it's satisfied by both *sql.DB and *sql.Tx
+type Querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// InTransaction runs fn in a transaction committed when fn succeeds,
+// fn runs in the caller's transaction when db is already one
+func InTransaction(ctx context.Context, db Querier, fn func(tx Querier) error) error {
+	pool, ok := db.(*sql.DB)
+	if !ok {
+		return fn(db)
+	}
+
+	tx, err := pool.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	if err = fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,41 @@
+package replication
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type fakeTx struct {
+	Querier
+}
+
+var _ = Describe("InTransaction", func() {
+	It("should run in the caller's transaction", func() {
+		tx := fakeTx{}
+		var got Querier
+		err := InTransaction(context.Background(), tx, func(q Querier) error {
+			got = q
+			return nil
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(got).To(Equal(tx))
+	})
+
+	It("should report failure of the transaction's pool", func() {
+		db, err := sql.Open("postgres", "postgresql://user@127.0.0.1:1/db?sslmode=disable&connect_timeout=1")
+		Expect(err).NotTo(HaveOccurred())
+		defer db.Close()
+
+		called := false
+		err = InTransaction(context.Background(), db, func(Querier) error {
+			called = true
+			return errors.New("unreachable")
+		})
+		Expect(err).To(HaveOccurred())
+		Expect(called).To(BeFalse())
+	})
+})
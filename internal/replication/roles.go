@@ -0,0 +1,103 @@
+package replication
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// GeneratePassword returns a random password of a replication role
+func GeneratePassword() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// CheckReplicationRole returns sql.ErrNoRows when the role doesn't exist
+// and ErrWrongAttributes when it can't log in or replicate
+func CheckReplicationRole(ctx context.Context, db Querier, name string) error {
+	row := db.QueryRowContext(ctx, `SELECT rolcanlogin AND rolreplication
+									  FROM pg_roles
+									 WHERE rolname = $1`, name)
+	var valid bool
+	if err := row.Scan(&valid); err != nil {
+		return err
+	}
+	if !valid {
+		return ErrWrongAttributes
+	}
+	return nil
+}
+
+func CreateReplicationRole(ctx context.Context, db Querier, name, password string) error {
+	sql := fmt.Sprintf(`CREATE ROLE %s LOGIN REPLICATION PASSWORD %s`,
+		pq.QuoteIdentifier(name), pq.QuoteLiteral(password))
+	_, err := db.ExecContext(ctx, sql)
+	return err
+}
+
+// AlterReplicationRole sets the role's attributes and password
+func AlterReplicationRole(ctx context.Context, db Querier, name, password string) error {
+	sql := fmt.Sprintf(`ALTER ROLE %s LOGIN REPLICATION PASSWORD %s`,
+		pq.QuoteIdentifier(name), pq.QuoteLiteral(password))
+	_, err := db.ExecContext(ctx, sql)
+	return err
+}
+
+// CheckReplicationRoleGrants returns tables the role can't read
+func CheckReplicationRoleGrants(ctx context.Context, db Querier, name string, tables []PgTable) ([]PgTable, error) {
+	missing := make([]PgTable, 0)
+	for _, table := range tables {
+		row := db.QueryRowContext(ctx, `SELECT has_schema_privilege($1, $2, 'USAGE')
+											   AND has_table_privilege($1, format('%I.%I', $2, $3), 'SELECT')`,
+			name, table.Schema, table.Name)
+		var granted bool
+		if err := row.Scan(&granted); err != nil {
+			return nil, err
+		}
+		if !granted {
+			missing = append(missing, table)
+		}
+	}
+	return missing, nil
+}
+
+// GrantReplicationRoleTable allows the role to copy the table's initial data
+func GrantReplicationRoleTable(ctx context.Context, db Querier, name string, table PgTable) error {
+	return InTransaction(ctx, db, func(tx Querier) error {
+		sql := fmt.Sprintf(`GRANT USAGE ON SCHEMA %s TO %s`,
+			pq.QuoteIdentifier(table.Schema), pq.QuoteIdentifier(name))
+		if _, err := tx.ExecContext(ctx, sql); err != nil {
+			return err
+		}
+		sql = fmt.Sprintf(`GRANT SELECT ON %s.%s TO %s`,
+			pq.QuoteIdentifier(table.Schema), pq.QuoteIdentifier(table.Name), pq.QuoteIdentifier(name))
+		_, err := tx.ExecContext(ctx, sql)
+		return err
+	})
+}
+
+// DropReplicationRole revokes the role's privileges in the database and drops it
+func DropReplicationRole(ctx context.Context, db Querier, name string) error {
+	err := CheckReplicationRole(ctx, db, name)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil && err != ErrWrongAttributes {
+		return err
+	}
+
+	return InTransaction(ctx, db, func(tx Querier) error {
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf(`DROP OWNED BY %s`, pq.QuoteIdentifier(name))); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, fmt.Sprintf(`DROP ROLE %s`, pq.QuoteIdentifier(name)))
+		return err
+	})
+}
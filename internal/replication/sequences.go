@@ -0,0 +1,176 @@
+package replication
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// ErrSequenceNotReadable is returned for a sequence whose last value the user can't read,
+// pg_sequences reports it as NULL like the last value of a sequence that hasn't been used
+var ErrSequenceNotReadable = errors.New("missing USAGE or SELECT privilege on the sequence")
+
+type PgSequence struct {
+	Schema string
+	Name   string
+	// published table and column using the sequence
+	Table  PgTable
+	Column string
+	// sequence of an identity column, created implicitly with the column
+	Identity  bool
+	DataType  string
+	Start     int64
+	Increment int64
+	Min       int64
+	Max       int64
+	Cache     int64
+	Cycle     bool
+	LastValue sql.NullInt64
+	// the user can read LastValue, it's NULL without USAGE or SELECT on the sequence
+	Readable bool
+}
+
+// PublicationTableSequences reads sequences owned by or used in defaults of the published columns
+func PublicationTableSequences(ctx context.Context, db Querier, table PgTable) ([]PgSequence, error) {
+	rows, err := db.QueryContext(ctx, `WITH `+publishedColumnsQuery+`
+		SELECT DISTINCT ON (s.oid)
+			   sn.nspname,
+			   s.relname,
+			   a.attname,
+			   a.attidentity <> '',
+			   ps.data_type::text,
+			   ps.start_value,
+			   ps.increment_by,
+			   ps.min_value,
+			   ps.max_value,
+			   ps.cache_size,
+			   ps.cycle,
+			   ps.last_value,
+			   has_sequence_privilege(s.oid, 'USAGE, SELECT')
+		  FROM published p
+		  JOIN pg_attribute a ON a.attrelid = p.attrelid AND a.attnum = p.attnum
+		  LEFT JOIN pg_attrdef ad ON ad.adrelid = p.attrelid AND ad.adnum = p.attnum
+		  JOIN pg_depend d ON (d.classid = 'pg_class'::regclass
+							   AND d.refclassid = 'pg_class'::regclass
+							   AND d.refobjid = p.attrelid
+							   AND d.refobjsubid = p.attnum
+							   AND d.deptype IN ('a', 'i'))
+						   OR (d.classid = 'pg_attrdef'::regclass
+							   AND d.objid = ad.oid
+							   AND d.refclassid = 'pg_class'::regclass)
+		  JOIN pg_class s ON s.relkind = 'S'
+						 AND s.oid = CASE WHEN d.classid = 'pg_attrdef'::regclass
+										  THEN d.refobjid ELSE d.objid END
+		  JOIN pg_namespace sn ON s.relnamespace = sn.oid
+		  JOIN pg_sequences ps ON ps.schemaname = sn.nspname AND ps.sequencename = s.relname
+		 ORDER BY s.oid`,
+		table.Schema, table.Name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sequences := make([]PgSequence, 0)
+	for rows.Next() {
+		seq := PgSequence{Table: table}
+		err = rows.Scan(
+			&seq.Schema,
+			&seq.Name,
+			&seq.Column,
+			&seq.Identity,
+			&seq.DataType,
+			&seq.Start,
+			&seq.Increment,
+			&seq.Min,
+			&seq.Max,
+			&seq.Cache,
+			&seq.Cycle,
+			&seq.LastValue,
+			&seq.Readable,
+		)
+		if err != nil {
+			return nil, err
+		}
+		sequences = append(sequences, seq)
+	}
+	return sequences, rows.Err()
+}
+
+func createSequenceSQL(seq PgSequence) string {
+	cycle := "NO CYCLE"
+	if seq.Cycle {
+		cycle = "CYCLE"
+	}
+	return fmt.Sprintf(`CREATE SEQUENCE IF NOT EXISTS %s.%s AS %s INCREMENT BY %d MINVALUE %d MAXVALUE %d START WITH %d CACHE %d %s`,
+		pq.QuoteIdentifier(seq.Schema), pq.QuoteIdentifier(seq.Name),
+		seq.DataType, seq.Increment, seq.Min, seq.Max, seq.Start, seq.Cache, cycle)
+}
+
+func CreateSubscriptionSequence(ctx context.Context, db Querier, seq PgSequence) error {
+	_, err := db.ExecContext(ctx, createSequenceSQL(seq))
+	return err
+}
+
+// subscriptionSequenceName resolves the sequence on the subscriber, identity columns
+// have their own sequence which does not need to have the publisher's name
+func subscriptionSequenceName(ctx context.Context, db Querier, seq PgSequence) (string, error) {
+	if !seq.Identity {
+		return pq.QuoteIdentifier(seq.Schema) + "." + pq.QuoteIdentifier(seq.Name), nil
+	}
+
+	row := db.QueryRowContext(ctx, `SELECT pg_get_serial_sequence($1, $2)`,
+		pq.QuoteIdentifier(seq.Table.Schema)+"."+pq.QuoteIdentifier(seq.Table.Name), seq.Column)
+	var name sql.NullString
+	if err := row.Scan(&name); err != nil {
+		return "", err
+	}
+	if !name.Valid {
+		return "", sql.ErrNoRows
+	}
+	return name.String, nil
+}
+
+// CheckSubscriptionSequence returns last value of the subscriber's sequence,
+// sql.ErrNoRows is returned when the sequence does not exist and
+// ErrSequenceNotReadable when the user can't read its last value
+func CheckSubscriptionSequence(ctx context.Context, db Querier, seq PgSequence) (sql.NullInt64, error) {
+	var lastValue sql.NullInt64
+
+	name, err := subscriptionSequenceName(ctx, db, seq)
+	if err != nil {
+		return lastValue, err
+	}
+
+	var readable bool
+	row := db.QueryRowContext(ctx, `SELECT ps.last_value,
+							   has_sequence_privilege(c.oid, 'USAGE, SELECT')
+						  FROM pg_sequences ps
+						  JOIN pg_namespace n ON ps.schemaname = n.nspname
+						  JOIN pg_class c ON c.relnamespace = n.oid AND ps.sequencename = c.relname
+						 WHERE c.oid = to_regclass($1)`, name)
+	if err = row.Scan(&lastValue, &readable); err != nil {
+		return lastValue, err
+	}
+	if !readable {
+		return lastValue, ErrSequenceNotReadable
+	}
+	return lastValue, nil
+}
+
+// SyncSubscriptionSequence sets the subscriber's sequence to the publisher's last value
+func SyncSubscriptionSequence(ctx context.Context, db Querier, seq PgSequence) error {
+	if !seq.LastValue.Valid {
+		return nil
+	}
+
+	name, err := subscriptionSequenceName(ctx, db, seq)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.ExecContext(ctx, `SELECT setval($1::regclass, $2, true)`, name, seq.LastValue.Int64)
+	return err
+}
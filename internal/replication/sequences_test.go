@@ -0,0 +1,24 @@
+package replication
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("createSequenceSQL", func() {
+	It("should copy the publisher's sequence options", func() {
+		seq := PgSequence{Schema: "data", Name: "people_id_seq", DataType: "bigint",
+			Start: 1, Increment: 1, Min: 1, Max: 9223372036854775807, Cache: 1}
+
+		Expect(createSequenceSQL(seq)).To(Equal(`CREATE SEQUENCE IF NOT EXISTS "data"."people_id_seq" ` +
+			`AS bigint INCREMENT BY 1 MINVALUE 1 MAXVALUE 9223372036854775807 START WITH 1 CACHE 1 NO CYCLE`))
+	})
+
+	It("should create cycling sequence", func() {
+		seq := PgSequence{Schema: "data", Name: "ring", DataType: "integer",
+			Start: 10, Increment: -1, Min: 1, Max: 10, Cache: 5, Cycle: true}
+
+		Expect(createSequenceSQL(seq)).To(Equal(`CREATE SEQUENCE IF NOT EXISTS "data"."ring" ` +
+			`AS integer INCREMENT BY -1 MINVALUE 1 MAXVALUE 10 START WITH 10 CACHE 5 CYCLE`))
+	})
+})
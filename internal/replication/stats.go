@@ -0,0 +1,109 @@
+package replication
+
+import (
+	"context"
+	"database/sql"
+)
+
+// conflict statistics of pg_stat_subscription_stats available since PostgreSQL 18
+const conflictStatsVersion = 180000
+
+var conflictTypes = []string{
+	"insert_exists",
+	"update_origin_differs",
+	"update_exists",
+	"update_missing",
+	"delete_origin_differs",
+	"delete_missing",
+	"multiple_unique_conflicts",
+}
+
+type SubscriptionStats struct {
+	// positions in WAL in bytes, NULL when the apply worker isn't running
+	ReceivedLSN    sql.NullFloat64
+	LatestEndLSN   sql.NullFloat64
+	LastMessageAge sql.NullFloat64
+	ApplyErrors    int64
+	SyncErrors     int64
+	// conflict counts by type, empty before PostgreSQL 18
+	Conflicts map[string]int64
+}
+
+type SlotStats struct {
+	Active    bool
+	WalStatus sql.NullString
+	// WAL in bytes the subscriber hasn't confirmed yet
+	ConfirmedFlushLag sql.NullFloat64
+	// seconds, NULL when the walsender isn't running
+	ReplayLag sql.NullFloat64
+}
+
+// SubscriptionStatistics reads statistics of the subscription's apply worker
+func SubscriptionStatistics(ctx context.Context, db Querier, name string) (SubscriptionStats, error) {
+	stats := SubscriptionStats{Conflicts: make(map[string]int64)}
+
+	row := db.QueryRowContext(ctx, `SELECT max(received_lsn - '0/0'),
+							   max(latest_end_lsn - '0/0'),
+							   extract(epoch FROM now() - max(last_msg_receipt_time))
+						  FROM pg_stat_subscription
+						 WHERE subname = $1 AND relid IS NULL`, name)
+	err := row.Scan(&stats.ReceivedLSN, &stats.LatestEndLSN, &stats.LastMessageAge)
+	if err != nil {
+		return stats, err
+	}
+
+	row = db.QueryRowContext(ctx, `SELECT apply_error_count, sync_error_count, current_setting('server_version_num')::int
+						 FROM pg_stat_subscription_stats
+						WHERE subname = $1`, name)
+	var version int
+	err = row.Scan(&stats.ApplyErrors, &stats.SyncErrors, &version)
+	if err != nil || version < conflictStatsVersion {
+		return stats, err
+	}
+
+	conflicts := make([]int64, len(conflictTypes))
+	dest := make([]any, len(conflictTypes))
+	for idx := range conflicts {
+		dest[idx] = &conflicts[idx]
+	}
+	row = db.QueryRowContext(ctx, `SELECT confl_insert_exists,
+							  confl_update_origin_differs,
+							  confl_update_exists,
+							  confl_update_missing,
+							  confl_delete_origin_differs,
+							  confl_delete_missing,
+							  confl_multiple_unique_conflicts
+						 FROM pg_stat_subscription_stats
+						WHERE subname = $1`, name)
+	if err = row.Scan(dest...); err != nil {
+		return stats, err
+	}
+	for idx, conflictType := range conflictTypes {
+		stats.Conflicts[conflictType] = conflicts[idx]
+	}
+	return stats, nil
+}
+
+// PublicationSlotStatistics reads the publisher's state of the subscription's replication slot
+func PublicationSlotStatistics(ctx context.Context, db Querier, slot string) (SlotStats, error) {
+	var stats SlotStats
+	row := db.QueryRowContext(ctx, `SELECT s.active,
+							   s.wal_status,
+							   pg_current_wal_lsn() - s.confirmed_flush_lsn,
+							   extract(epoch FROM r.replay_lag)
+						  FROM pg_replication_slots s
+						  LEFT JOIN pg_stat_replication r ON r.pid = s.active_pid
+						 WHERE s.slot_name = $1`, slot)
+	err := row.Scan(&stats.Active, &stats.WalStatus, &stats.ConfirmedFlushLag, &stats.ReplayLag)
+	return stats, err
+}
+
+// SubscriptionWorkerRunning reports whether the subscription's apply worker is running
+func SubscriptionWorkerRunning(ctx context.Context, db Querier, name string) (bool, error) {
+	row := db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1
+										 FROM pg_stat_subscription
+										WHERE subname = $1 AND relid IS NULL AND pid IS NOT NULL)`, name)
+	var running bool
+	err := row.Scan(&running)
+	return running, err
+}
@@ -1,6 +1,7 @@
 package replication
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"reflect"
@@ -10,16 +11,51 @@ import (
 )
 
 type PgTableColumn struct {
-	Name                   string
-	Default                sql.NullString
-	Nullable               bool
-	Type                   string
-	CharacterMaximumLength sql.NullInt32
-	NumericPrecision       sql.NullInt32
-	NumericScale           sql.NullInt32
-	DatetimePrecision      sql.NullInt32
+	Name     string
+	Default  sql.NullString
+	Nullable bool
+	// type rendered by format_type including type modifiers
+	Type string
+	// identity column kind, empty for regular columns
+	Identity string
+	// generated column kind, Default holds the generation expression
+	Generated string
 }
 
+var (
+	IdentityAlways    = "a"
+	IdentityByDefault = "d"
+	GeneratedStored   = "s"
+)
+
+// condition selecting published columns of the table aliased as a (pg_attribute),
+// c (pg_class) and n (pg_namespace). Partitioned table not published via its root
+// is listed in pg_publication_tables by its partitions. Generated columns are not
+// part of the replication stream, stored generated columns are computed on the
+// subscriber when all columns they depend on are published.
+const publishedColumnCondition = `(EXISTS (SELECT 1
+				  FROM pg_publication_tables pt
+				  JOIN pg_namespace ptn ON ptn.nspname = pt.schemaname
+				  JOIN pg_class ptc ON ptc.relnamespace = ptn.oid AND ptc.relname = pt.tablename
+				 WHERE ptc.oid IN (SELECT relid FROM pg_partition_tree(c.oid))
+				   AND a.attgenerated = ''
+				   AND a.attname = ANY(pt.attnames))
+		OR (a.attgenerated = 's' AND NOT EXISTS (
+			SELECT 1
+			  FROM pg_attrdef gad
+			  JOIN pg_depend gd ON gd.classid = 'pg_attrdef'::regclass
+							   AND gd.objid = gad.oid
+							   AND gd.refclassid = 'pg_class'::regclass
+							   AND gd.refobjid = gad.adrelid
+			  JOIN pg_attribute ga ON ga.attrelid = gd.refobjid AND ga.attnum = gd.refobjsubid
+			 WHERE gad.adrelid = a.attrelid AND gad.adnum = a.attnum
+			   AND NOT EXISTS (SELECT 1
+								 FROM pg_publication_tables pt
+								 JOIN pg_namespace ptn ON ptn.nspname = pt.schemaname
+								 JOIN pg_class ptc ON ptc.relnamespace = ptn.oid AND ptc.relname = pt.tablename
+								WHERE ptc.oid IN (SELECT relid FROM pg_partition_tree(c.oid))
+								  AND ga.attname = ANY(pt.attnames)))))`
+
 type PgTable struct {
 	Schema string `json:"schema"`
 	Name   string `json:"name"`
@@ -27,7 +63,11 @@ type PgTable struct {
 
 type PgTableDetail struct {
 	PgTable
-	Columns []PgTableColumn
+	Columns     []PgTableColumn
+	Constraints []PgConstraint
+	// partition key of a partitioned table, empty for regular tables
+	PartitionKey string
+	Partitions   []PgPartition
 }
 
 type PgIndex struct {
@@ -35,13 +75,27 @@ type PgIndex struct {
 	Def  string
 }
 
-func PublicationTables(db *sql.DB, pubname string) ([]PgTable, error) {
-	rows, err := db.Query(`SELECT n.nspname AS schema, r.relname AS name
-							 FROM pg_publication p
-							 JOIN pg_publication_rel pr ON p.oid = pr.prpubid
-							 JOIN pg_class r ON pr.prrelid = r.oid
+// PublicationTables lists tables of the publication, partitions are collapsed into
+// their topmost published ancestor. Publications FOR ALL TABLES and TABLES IN SCHEMA
+// are refused by CheckPublication, only tables listed by the publication are published.
+func PublicationTables(ctx context.Context, db Querier, pubname string) ([]PgTable, error) {
+	rows, err := db.QueryContext(ctx, `SELECT DISTINCT n.nspname AS schema, r.relname AS name
+							 FROM pg_publication_tables pt
+							 JOIN pg_publication p ON p.pubname = pt.pubname
+							 JOIN pg_namespace ptn ON ptn.nspname = pt.schemaname
+							 JOIN pg_class ptc ON ptc.relnamespace = ptn.oid AND ptc.relname = pt.tablename
+							CROSS JOIN LATERAL (
+								SELECT COALESCE((
+									SELECT pa.relid
+									  FROM pg_partition_ancestors(ptc.oid) WITH ORDINALITY pa(relid, depth)
+									 WHERE pa.relid IN (SELECT prrelid FROM pg_publication_rel WHERE prpubid = p.oid)
+									 ORDER BY pa.depth DESC
+									 LIMIT 1), ptc.oid) AS relid
+							) top
+							 JOIN pg_class r ON r.oid = top.relid
 							 JOIN pg_namespace n ON r.relnamespace = n.oid
-							 WHERE p.pubname = $1`, pubname)
+							WHERE pt.pubname = $1
+							ORDER BY 1, 2`, pubname)
 	if err != nil {
 		return []PgTable{}, err
 	}
@@ -60,32 +114,31 @@ func PublicationTables(db *sql.DB, pubname string) ([]PgTable, error) {
 		}
 		tables = append(tables, PgTable{Schema: schema, Name: name})
 	}
-	return tables, nil
+	return tables, rows.Err()
 }
 
-func tableColumns(db *sql.DB, table PgTable, joinPublication bool) (PgTableDetail, error) {
+func tableColumns(ctx context.Context, db Querier, table PgTable, joinPublication bool) (PgTableDetail, error) {
 	sqlJoin := ""
 	if joinPublication {
-		sqlJoin = `JOIN pg_publication_tables pt
-                     ON c.table_schema = pt.schemaname
-                    AND c.table_name = pt.tablename
-                    AND c.column_name = ANY(pt.attnames)`
-	}
-	sql := fmt.Sprintf(`SELECT column_name,
-							   column_default,
-							  (is_nullable = 'YES'),
-							  data_type,
-							  character_maximum_length,
-							  numeric_precision,
-							  numeric_scale,
-							  datetime_precision
-						 FROM information_schema.columns c
-						 %s
-						WHERE c.table_schema = $1 AND c.table_name = $2
-						ORDER BY c.ordinal_position`,
+		sqlJoin = "AND " + publishedColumnCondition
+	}
+	sql := fmt.Sprintf(`SELECT a.attname,
+							   pg_get_expr(d.adbin, d.adrelid),
+							   NOT a.attnotnull,
+							   format_type(a.atttypid, a.atttypmod),
+							   a.attidentity,
+							   a.attgenerated
+						  FROM pg_attribute a
+						  JOIN pg_class c ON a.attrelid = c.oid
+						  JOIN pg_namespace n ON c.relnamespace = n.oid
+						  LEFT JOIN pg_attrdef d ON a.attrelid = d.adrelid AND a.attnum = d.adnum
+						 WHERE n.nspname = $1 AND c.relname = $2
+						   AND a.attnum > 0 AND NOT a.attisdropped
+						   %s
+						 ORDER BY a.attnum`,
 		sqlJoin)
 	tableDetail := PgTableDetail{}
-	rows, err := db.Query(sql, table.Schema, table.Name)
+	rows, err := db.QueryContext(ctx, sql, table.Schema, table.Name)
 	if err != nil {
 		return tableDetail, err
 	}
@@ -99,10 +152,8 @@ func tableColumns(db *sql.DB, table PgTable, joinPublication bool) (PgTableDetai
 			&col.Default,
 			&col.Nullable,
 			&col.Type,
-			&col.CharacterMaximumLength,
-			&col.NumericPrecision,
-			&col.NumericScale,
-			&col.DatetimePrecision,
+			&col.Identity,
+			&col.Generated,
 		)
 		if err != nil {
 			return tableDetail, err
@@ -116,18 +167,31 @@ func tableColumns(db *sql.DB, table PgTable, joinPublication bool) (PgTableDetai
 	return tableDetail, nil
 }
 
-func PublicationTableDetail(db *sql.DB, table PgTable) (PgTableDetail, error) {
-	return tableColumns(db, table, true)
+// PublicationTableDetail reads published columns as they should be created on the subscriber
+func PublicationTableDetail(ctx context.Context, db Querier, table PgTable) (PgTableDetail, error) {
+	tableDetail, err := tableColumns(ctx, db, table, true)
+	if err != nil {
+		return tableDetail, err
+	}
+
+	// replicated rows carry values of the identity columns,
+	// generating them by default keeps the subscriber usable after promotion
+	for i := range tableDetail.Columns {
+		if tableDetail.Columns[i].Identity == IdentityAlways {
+			tableDetail.Columns[i].Identity = IdentityByDefault
+		}
+	}
+	return tableDetail, nil
 }
 
-func CreateSubscriptionSchema(db *sql.DB, name string) error {
+func CreateSubscriptionSchema(ctx context.Context, db Querier, name string) error {
 	sql := fmt.Sprintf(`CREATE SCHEMA IF NOT EXISTS %s`, pq.QuoteIdentifier(name))
-	_, err := db.Exec(sql)
+	_, err := db.ExecContext(ctx, sql)
 	return err
 }
 
-func CheckSubscriptionSchema(db *sql.DB, name string) error {
-	row := db.QueryRow(`SELECT true
+func CheckSubscriptionSchema(ctx context.Context, db Querier, name string) error {
+	row := db.QueryRowContext(ctx, `SELECT true
 						  FROM pg_namespace n
 						 WHERE n.nspname = $1`, name)
 	var exists bool
@@ -135,10 +199,12 @@ func CheckSubscriptionSchema(db *sql.DB, name string) error {
 	return err
 }
 
-func CheckSubscriptionTable(db *sql.DB, table PgTable) error {
-	row := db.QueryRow(`SELECT true
-						  FROM pg_tables
-						 WHERE schemaname = $1 AND tablename = $2`, table.Schema, table.Name)
+func CheckSubscriptionTable(ctx context.Context, db Querier, table PgTable) error {
+	row := db.QueryRowContext(ctx, `SELECT true
+						  FROM pg_class c
+						  JOIN pg_namespace n ON c.relnamespace = n.oid
+						 WHERE n.nspname = $1 AND c.relname = $2
+						   AND c.relkind IN ('r', 'p')`, table.Schema, table.Name)
 	var exists bool
 	err := row.Scan(&exists)
 	return err
@@ -161,43 +227,104 @@ func createColumns(columns []PgTableColumn) string {
 	columnDefs := make([]string, len(columns))
 	for i, col := range columns {
 		columnDefs[i] = pq.QuoteIdentifier(col.Name) + " " + col.Type
-		if col.CharacterMaximumLength.Valid {
-			columnDefs[i] += fmt.Sprintf(" (%d)", col.CharacterMaximumLength.Int32)
-		}
-		if col.NumericPrecision.Valid {
-			columnDefs[i] += fmt.Sprintf(" (%d, %d)", col.NumericPrecision.Int32, col.NumericScale.Int32)
-		}
-		if col.DatetimePrecision.Valid {
-			columnDefs[i] += fmt.Sprintf(" (%d)", col.DatetimePrecision.Int32)
-		}
 		if !col.Nullable {
 			columnDefs[i] += " NOT NULL"
 		}
-		if col.Default.Valid {
+		switch col.Identity {
+		case IdentityAlways:
+			columnDefs[i] += " GENERATED ALWAYS AS IDENTITY"
+		case IdentityByDefault:
+			columnDefs[i] += " GENERATED BY DEFAULT AS IDENTITY"
+		}
+		if col.Generated == GeneratedStored {
+			columnDefs[i] += " GENERATED ALWAYS AS (" + col.Default.String + ") STORED"
+		} else if col.Default.Valid {
 			columnDefs[i] += " DEFAULT " + col.Default.String
 		}
 	}
 	return strings.Join(columnDefs, ", ")
 }
 
-func CreateSubscriptionTable(db *sql.DB, table PgTableDetail) error {
+func CreateSubscriptionTable(ctx context.Context, db Querier, table PgTableDetail) error {
 	tableColumns := createColumns(table.Columns)
 	sql := fmt.Sprintf(`CREATE TABLE %s.%s (%s)`,
 		pq.QuoteIdentifier(table.Schema), pq.QuoteIdentifier(table.Name), tableColumns)
-	_, err := db.Exec(sql)
+	if table.PartitionKey != "" {
+		sql += " PARTITION BY " + table.PartitionKey
+	}
+	_, err := db.ExecContext(ctx, sql)
 	return err
 }
 
-func RenameSubscriptionTable(db *sql.DB, table, newTable PgTable) error {
+// CheckSubscriptionColumnDefaults returns columns of the table whose default expression
+// differs on the subscriber, identity and generated columns are skipped
+func CheckSubscriptionColumnDefaults(ctx context.Context, db Querier, table PgTableDetail) ([]PgTableColumn, error) {
+	subscriptionTable, err := tableColumns(ctx, db, table.PgTable, false)
+	if err != nil {
+		return nil, err
+	}
+
+	existing := make(map[string]PgTableColumn, len(subscriptionTable.Columns))
+	for _, col := range subscriptionTable.Columns {
+		existing[col.Name] = col
+	}
+
+	changed := make([]PgTableColumn, 0)
+	for _, col := range table.Columns {
+		found, ok := existing[col.Name]
+		if !ok || col.Identity != "" || col.Generated != "" || found.Identity != "" || found.Generated != "" {
+			continue
+		}
+		if found.Default != col.Default {
+			changed = append(changed, col)
+		}
+	}
+	return changed, nil
+}
+
+// AlterSubscriptionColumnDefault sets the column's default expression,
+// the default is dropped when the column has none
+func AlterSubscriptionColumnDefault(ctx context.Context, db Querier, table PgTable, col PgTableColumn) error {
+	action := "DROP DEFAULT"
+	if col.Default.Valid {
+		action = "SET DEFAULT " + col.Default.String
+	}
+	sql := fmt.Sprintf(`ALTER TABLE %s.%s ALTER COLUMN %s %s`,
+		pq.QuoteIdentifier(table.Schema), pq.QuoteIdentifier(table.Name), pq.QuoteIdentifier(col.Name), action)
+	_, err := db.ExecContext(ctx, sql)
+	return err
+}
+
+// RenameSubscriptionTable renames the table and moves it into newTable's schema
+func RenameSubscriptionTable(ctx context.Context, db Querier, table, newTable PgTable) error {
 	sql := fmt.Sprintf(`ALTER TABLE IF EXISTS %s.%s RENAME TO %s`,
 		pq.QuoteIdentifier(table.Schema), pq.QuoteIdentifier(table.Name),
 		pq.QuoteIdentifier(newTable.Name))
-	_, err := db.Exec(sql)
+	if table.Schema == newTable.Schema {
+		_, err := db.ExecContext(ctx, sql)
+		return err
+	}
+
+	return InTransaction(ctx, db, func(tx Querier) error {
+		if _, err := tx.ExecContext(ctx, sql); err != nil {
+			return err
+		}
+		sql = fmt.Sprintf(`ALTER TABLE IF EXISTS %s.%s SET SCHEMA %s`,
+			pq.QuoteIdentifier(table.Schema), pq.QuoteIdentifier(newTable.Name),
+			pq.QuoteIdentifier(newTable.Schema))
+		_, err := tx.ExecContext(ctx, sql)
+		return err
+	})
+}
+
+func DropSubscriptionTable(ctx context.Context, db Querier, table PgTable) error {
+	sql := fmt.Sprintf(`DROP TABLE IF EXISTS %s.%s`, pq.QuoteIdentifier(table.Schema), pq.QuoteIdentifier(table.Name))
+	_, err := db.ExecContext(ctx, sql)
 	return err
 }
 
-func CheckSubscriptionTableDetail(db *sql.DB, table PgTableDetail) error {
-	subscriptionTable, err := tableColumns(db, PgTable{Schema: table.Schema, Name: table.Name}, false)
+func CheckSubscriptionTableDetail(ctx context.Context, db Querier, table PgTableDetail) error {
+	subscriptionTable, err := tableColumns(ctx, db, PgTable{Schema: table.Schema, Name: table.Name}, false)
 	if err != nil {
 		return err
 	}
@@ -205,5 +332,21 @@ func CheckSubscriptionTableDetail(db *sql.DB, table PgTableDetail) error {
 	if !equalColumns(table.Columns, subscriptionTable.Columns) {
 		return ErrWrongAttributes
 	}
+
+	partitionKey, err := tablePartitionKey(ctx, db, table.PgTable)
+	if err != nil {
+		return err
+	}
+	if partitionKey != table.PartitionKey {
+		return ErrWrongAttributes
+	}
+
+	missing, err := CheckSubscriptionConstraints(ctx, db, table.PgTable, table.Constraints)
+	if err != nil {
+		return err
+	}
+	if len(missing) > 0 {
+		return ErrWrongAttributes
+	}
 	return nil
 }
@@ -0,0 +1,30 @@
+package replication
+
+import (
+	"database/sql"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("createColumns", func() {
+	It("should render format_type types verbatim", func() {
+		columns := []PgTableColumn{
+			{Name: "id", Type: "integer", Identity: IdentityByDefault},
+			{Name: "tags", Nullable: true, Type: "text[]"},
+			{Name: "mood", Nullable: true, Type: "published_data.mood"},
+			{Name: "period", Nullable: true, Type: "interval day to second(2)"},
+			{Name: "created", Type: "timestamp(3) with time zone",
+				Default: sql.NullString{String: "now()", Valid: true}},
+			{Name: "year", Nullable: true, Type: "double precision", Generated: GeneratedStored,
+				Default: sql.NullString{String: "EXTRACT(year FROM created)", Valid: true}},
+		}
+
+		Expect(createColumns(columns)).To(Equal(`"id" integer NOT NULL GENERATED BY DEFAULT AS IDENTITY, ` +
+			`"tags" text[], ` +
+			`"mood" published_data.mood, ` +
+			`"period" interval day to second(2), ` +
+			`"created" timestamp(3) with time zone NOT NULL DEFAULT now(), ` +
+			`"year" double precision GENERATED ALWAYS AS (EXTRACT(year FROM created)) STORED`))
+	})
+})
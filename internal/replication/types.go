@@ -0,0 +1,308 @@
+package replication
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+type TypeKind string
+
+var (
+	EnumType      TypeKind = "e"
+	DomainType    TypeKind = "d"
+	CompositeType TypeKind = "c"
+)
+
+type PgType struct {
+	Schema string
+	Name   string
+	Kind   TypeKind
+	// enum labels in their sort order
+	Labels []string
+	// domain base type rendered by format_type
+	BaseType string
+	NotNull  bool
+	Default  sql.NullString
+	// domain check constraints
+	Checks []string
+	// composite type attributes, quoted name and type
+	Attributes []string
+}
+
+type PgExtension struct {
+	Name   string
+	Schema string
+}
+
+type PgDependencies struct {
+	Extensions []PgExtension
+	// types ordered so that each type is listed after its dependencies
+	Types []PgType
+}
+
+// published columns of the table
+const publishedColumnsQuery = `published AS (
+		SELECT a.attrelid, a.attnum, a.atttypid
+		  FROM pg_attribute a
+		  JOIN pg_class c ON a.attrelid = c.oid
+		  JOIN pg_namespace n ON c.relnamespace = n.oid
+		 WHERE n.nspname = $1 AND c.relname = $2
+		   AND a.attnum > 0 AND NOT a.attisdropped
+		   AND ` + publishedColumnCondition + `
+	)`
+
+// published columns of the table and all types they depend on through arrays,
+// domains and composite types, depth is used to order the types by dependencies
+const dependenciesQuery = `WITH RECURSIVE ` + publishedColumnsQuery + `, deps(oid, depth) AS (
+		SELECT atttypid, 0 FROM published
+		UNION
+		SELECT x.dep, d.depth + 1
+		  FROM deps d
+		  JOIN pg_type t ON t.oid = d.oid
+		 CROSS JOIN LATERAL (
+			SELECT NULLIF(t.typelem, 0)
+			UNION ALL
+			SELECT NULLIF(t.typbasetype, 0)
+			UNION ALL
+			SELECT ca.atttypid
+			  FROM pg_attribute ca
+			 WHERE ca.attrelid = t.typrelid AND ca.attnum > 0 AND NOT ca.attisdropped
+		 ) x(dep)
+		 WHERE x.dep IS NOT NULL
+	)`
+
+func dependencyExtensions(ctx context.Context, db Querier, table PgTable) ([]PgExtension, error) {
+	rows, err := db.QueryContext(ctx, dependenciesQuery+`
+		SELECT DISTINCT e.extname, en.nspname
+		  FROM pg_extension e
+		  JOIN pg_namespace en ON e.extnamespace = en.oid
+		  JOIN pg_depend ed ON ed.refclassid = 'pg_extension'::regclass
+						   AND ed.refobjid = e.oid
+						   AND ed.deptype = 'e'
+		 WHERE (ed.classid = 'pg_type'::regclass AND ed.objid IN (SELECT oid FROM deps))
+			OR (ed.classid = 'pg_proc'::regclass AND ed.objid IN (
+				SELECT fd.refobjid
+				  FROM published p
+				  JOIN pg_attrdef ad ON ad.adrelid = p.attrelid AND ad.adnum = p.attnum
+				  JOIN pg_depend fd ON fd.classid = 'pg_attrdef'::regclass
+								   AND fd.objid = ad.oid
+								   AND fd.refclassid = 'pg_proc'::regclass))
+		 ORDER BY e.extname`,
+		table.Schema, table.Name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	extensions := make([]PgExtension, 0)
+	for rows.Next() {
+		var ext PgExtension
+		if err = rows.Scan(&ext.Name, &ext.Schema); err != nil {
+			return nil, err
+		}
+		extensions = append(extensions, ext)
+	}
+	return extensions, rows.Err()
+}
+
+func dependencyTypes(ctx context.Context, db Querier, table PgTable) ([]PgType, error) {
+	rows, err := db.QueryContext(ctx, dependenciesQuery+`
+		SELECT tn.nspname,
+			   t.typname,
+			   t.typtype,
+			   COALESCE((SELECT array_agg(e.enumlabel ORDER BY e.enumsortorder)
+						   FROM pg_enum e
+						  WHERE e.enumtypid = t.oid), '{}'),
+			   COALESCE(format_type(NULLIF(t.typbasetype, 0), t.typtypmod), ''),
+			   t.typnotnull,
+			   t.typdefault,
+			   COALESCE((SELECT array_agg(pg_get_constraintdef(con.oid) ORDER BY con.conname)
+						   FROM pg_constraint con
+						  WHERE con.contypid = t.oid AND con.contype = 'c'), '{}'),
+			   COALESCE((SELECT array_agg(quote_ident(ca.attname) || ' ' || format_type(ca.atttypid, ca.atttypmod)
+										  ORDER BY ca.attnum)
+						   FROM pg_attribute ca
+						  WHERE ca.attrelid = t.typrelid AND ca.attnum > 0 AND NOT ca.attisdropped), '{}')
+		  FROM (SELECT oid, max(depth) AS depth FROM deps GROUP BY oid) d
+		  JOIN pg_type t ON t.oid = d.oid
+		  JOIN pg_namespace tn ON t.typnamespace = tn.oid
+		  LEFT JOIN pg_class tc ON t.typrelid = tc.oid
+		 WHERE t.typtype IN ('e', 'd', 'c')
+		   AND (t.typtype <> 'c' OR tc.relkind = 'c')
+		   AND NOT EXISTS (SELECT 1
+							 FROM pg_depend ed
+							WHERE ed.classid = 'pg_type'::regclass
+							  AND ed.objid = t.oid
+							  AND ed.deptype = 'e')
+		 ORDER BY d.depth DESC, tn.nspname, t.typname`,
+		table.Schema, table.Name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	types := make([]PgType, 0)
+	for rows.Next() {
+		var typ PgType
+		err = rows.Scan(
+			&typ.Schema,
+			&typ.Name,
+			&typ.Kind,
+			pq.Array(&typ.Labels),
+			&typ.BaseType,
+			&typ.NotNull,
+			&typ.Default,
+			pq.Array(&typ.Checks),
+			pq.Array(&typ.Attributes),
+		)
+		if err != nil {
+			return nil, err
+		}
+		types = append(types, typ)
+	}
+	return types, rows.Err()
+}
+
+// PublicationTableDependencies reads extensions and user defined types
+// required by the published columns of the table
+func PublicationTableDependencies(ctx context.Context, db Querier, table PgTable) (PgDependencies, error) {
+	var deps PgDependencies
+	var err error
+
+	deps.Extensions, err = dependencyExtensions(ctx, db, table)
+	if err != nil {
+		return deps, err
+	}
+
+	deps.Types, err = dependencyTypes(ctx, db, table)
+	return deps, err
+}
+
+func CheckSubscriptionExtension(ctx context.Context, db Querier, name string) error {
+	row := db.QueryRowContext(ctx, `SELECT true
+						  FROM pg_extension e
+						 WHERE e.extname = $1`, name)
+	var exists bool
+	err := row.Scan(&exists)
+	return err
+}
+
+func CreateSubscriptionExtension(ctx context.Context, db Querier, ext PgExtension) error {
+	sql := fmt.Sprintf(`CREATE EXTENSION IF NOT EXISTS %s WITH SCHEMA %s`,
+		pq.QuoteIdentifier(ext.Name), pq.QuoteIdentifier(ext.Schema))
+	_, err := db.ExecContext(ctx, sql)
+	return err
+}
+
+func CheckSubscriptionType(ctx context.Context, db Querier, typ PgType) error {
+	row := db.QueryRowContext(ctx, `SELECT true
+						  FROM pg_type t
+						  JOIN pg_namespace n ON t.typnamespace = n.oid
+						 WHERE n.nspname = $1 AND t.typname = $2`, typ.Schema, typ.Name)
+	var exists bool
+	err := row.Scan(&exists)
+	return err
+}
+
+func quoteLiterals(values []string) []string {
+	quoted := make([]string, len(values))
+	for i, value := range values {
+		quoted[i] = pq.QuoteLiteral(value)
+	}
+	return quoted
+}
+
+func createTypeSQL(typ PgType) (string, error) {
+	name := pq.QuoteIdentifier(typ.Schema) + "." + pq.QuoteIdentifier(typ.Name)
+
+	switch typ.Kind {
+	case EnumType:
+		return fmt.Sprintf(`CREATE TYPE %s AS ENUM (%s)`,
+			name, strings.Join(quoteLiterals(typ.Labels), ", ")), nil
+
+	case DomainType:
+		sql := fmt.Sprintf(`CREATE DOMAIN %s AS %s`, name, typ.BaseType)
+		if typ.Default.Valid {
+			sql += " DEFAULT " + typ.Default.String
+		}
+		if typ.NotNull {
+			sql += " NOT NULL"
+		}
+		for _, check := range typ.Checks {
+			sql += " " + check
+		}
+		return sql, nil
+
+	case CompositeType:
+		return fmt.Sprintf(`CREATE TYPE %s AS (%s)`, name, strings.Join(typ.Attributes, ", ")), nil
+	}
+
+	return "", fmt.Errorf("unsupported kind '%s' of type %s", typ.Kind, name)
+}
+
+func CreateSubscriptionType(ctx context.Context, db Querier, typ PgType) error {
+	sql, err := createTypeSQL(typ)
+	if err != nil {
+		return err
+	}
+	_, err = db.ExecContext(ctx, sql)
+	return err
+}
+
+func subscriptionEnumLabels(ctx context.Context, db Querier, typ PgType) ([]string, error) {
+	row := db.QueryRowContext(ctx, `SELECT COALESCE(array_agg(e.enumlabel ORDER BY e.enumsortorder), '{}')
+						  FROM pg_enum e
+						  JOIN pg_type t ON e.enumtypid = t.oid
+						  JOIN pg_namespace n ON t.typnamespace = n.oid
+						 WHERE n.nspname = $1 AND t.typname = $2`, typ.Schema, typ.Name)
+	var labels []string
+	err := row.Scan(pq.Array(&labels))
+	return labels, err
+}
+
+// addEnumLabelsSQL returns statements adding labels missing in existing,
+// new labels are placed next to their publisher's neighbours
+func addEnumLabelsSQL(typ PgType, existing []string) []string {
+	name := pq.QuoteIdentifier(typ.Schema) + "." + pq.QuoteIdentifier(typ.Name)
+	statements := make([]string, 0)
+
+	for i, label := range typ.Labels {
+		if slices.Contains(existing, label) {
+			continue
+		}
+
+		sql := fmt.Sprintf(`ALTER TYPE %s ADD VALUE IF NOT EXISTS %s`, name, pq.QuoteLiteral(label))
+		if i > 0 {
+			sql += " AFTER " + pq.QuoteLiteral(typ.Labels[i-1])
+		} else if next := slices.IndexFunc(typ.Labels, func(l string) bool {
+			return slices.Contains(existing, l)
+		}); next >= 0 {
+			sql += " BEFORE " + pq.QuoteLiteral(typ.Labels[next])
+		}
+		statements = append(statements, sql)
+		existing = append(existing, label)
+	}
+	return statements
+}
+
+// AddSubscriptionEnumLabels adds labels added to the publisher's enum,
+// returns number of added labels
+func AddSubscriptionEnumLabels(ctx context.Context, db Querier, typ PgType) (int, error) {
+	existing, err := subscriptionEnumLabels(ctx, db, typ)
+	if err != nil {
+		return 0, err
+	}
+
+	statements := addEnumLabelsSQL(typ, existing)
+	for _, sql := range statements {
+		if _, err = db.ExecContext(ctx, sql); err != nil {
+			return 0, err
+		}
+	}
+	return len(statements), nil
+}
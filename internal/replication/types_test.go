@@ -0,0 +1,50 @@
+package replication
+
+import (
+	"database/sql"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("PgType", func() {
+	Context("createTypeSQL", func() {
+		It("should create enum", func() {
+			sql, err := createTypeSQL(PgType{Schema: "data", Name: "mood", Kind: EnumType,
+				Labels: []string{"sad", "it's ok"}})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(sql).To(Equal(`CREATE TYPE "data"."mood" AS ENUM ('sad', 'it''s ok')`))
+		})
+
+		It("should create domain", func() {
+			sql, err := createTypeSQL(PgType{Schema: "data", Name: "positive", Kind: DomainType,
+				BaseType: "numeric(10,2)", NotNull: true, Default: sql.NullString{String: "1", Valid: true},
+				Checks: []string{"CHECK (VALUE > 0::numeric)"}})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(sql).To(Equal(`CREATE DOMAIN "data"."positive" AS numeric(10,2) DEFAULT 1 NOT NULL ` +
+				`CHECK (VALUE > 0::numeric)`))
+		})
+
+		It("should create composite type", func() {
+			sql, err := createTypeSQL(PgType{Schema: "data", Name: "address", Kind: CompositeType,
+				Attributes: []string{"street text", "zip \"data\".positive"}})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(sql).To(Equal(`CREATE TYPE "data"."address" AS (street text, zip "data".positive)`))
+		})
+	})
+
+	Context("addEnumLabelsSQL", func() {
+		typ := PgType{Schema: "data", Name: "mood", Kind: EnumType, Labels: []string{"sad", "ok", "happy"}}
+
+		It("should not alter complete enum", func() {
+			Expect(addEnumLabelsSQL(typ, []string{"sad", "ok", "happy"})).To(BeEmpty())
+		})
+
+		It("should keep publisher's label order", func() {
+			Expect(addEnumLabelsSQL(typ, []string{"ok"})).To(Equal([]string{
+				`ALTER TYPE "data"."mood" ADD VALUE IF NOT EXISTS 'sad' BEFORE 'ok'`,
+				`ALTER TYPE "data"."mood" ADD VALUE IF NOT EXISTS 'happy' AFTER 'ok'`,
+			}))
+		})
+	})
+})
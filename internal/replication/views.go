@@ -0,0 +1,160 @@
+package replication
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+const viewCommentPrefix = "managed by pg-replication-operator, checksum "
+
+type PgViewColumn struct {
+	// column of the source table
+	Name string
+	// name of the column in the view, empty keeps the column name
+	Alias string
+	// type the column is cast to, empty keeps the column type
+	Cast string
+}
+
+type PgView struct {
+	PgTable
+	// replicated table the view selects from
+	Source PgTable
+	Owner  string
+	// selected columns, all source columns are selected when empty
+	Columns []PgViewColumn
+	// row filter used in the WHERE clause
+	Filter string
+}
+
+func viewQuerySQL(view PgView, source PgTableDetail) string {
+	columns := view.Columns
+	if len(columns) == 0 {
+		columns = make([]PgViewColumn, len(source.Columns))
+		for i, col := range source.Columns {
+			columns[i] = PgViewColumn{Name: col.Name}
+		}
+	}
+
+	columnDefs := make([]string, len(columns))
+	for i, col := range columns {
+		columnDefs[i] = pq.QuoteIdentifier(col.Name)
+		if col.Cast != "" {
+			columnDefs[i] += "::" + col.Cast
+		}
+		if col.Alias != "" {
+			columnDefs[i] += " AS " + pq.QuoteIdentifier(col.Alias)
+		} else if col.Cast != "" {
+			columnDefs[i] += " AS " + pq.QuoteIdentifier(col.Name)
+		}
+	}
+
+	sql := fmt.Sprintf(`SELECT %s FROM %s.%s`, strings.Join(columnDefs, ", "),
+		pq.QuoteIdentifier(view.Source.Schema), pq.QuoteIdentifier(view.Source.Name))
+	if view.Filter != "" {
+		sql += " WHERE " + view.Filter
+	}
+	return sql
+}
+
+// viewComment identifies the view definition together with the source's columns,
+// the view is recreated whenever any of them changes
+func viewComment(view PgView, source PgTableDetail) string {
+	hash := sha256.New()
+	hash.Write([]byte(viewQuerySQL(view, source)))
+	hash.Write([]byte("\n" + view.Owner))
+	for _, col := range source.Columns {
+		hash.Write([]byte("\n" + col.Name + " " + col.Type))
+	}
+	return viewCommentPrefix + hex.EncodeToString(hash.Sum(nil))
+}
+
+// CheckSubscriptionView returns sql.ErrNoRows for missing view and ErrWrongAttributes
+// when the view has been created from a different definition or selects from
+// another table than the current source, e.g. after the source has been renamed
+func CheckSubscriptionView(ctx context.Context, db Querier, view PgView, source PgTableDetail) error {
+	row := db.QueryRowContext(ctx, `SELECT COALESCE(obj_description(c.oid, 'pg_class'), ''),
+								  EXISTS (SELECT 1
+											FROM pg_rewrite r
+											JOIN pg_depend d ON d.classid = 'pg_rewrite'::regclass AND d.objid = r.oid
+										   WHERE r.ev_class = c.oid
+											 AND d.refclassid = 'pg_class'::regclass
+											 AND d.refobjid = to_regclass($3))
+						  FROM pg_class c
+						  JOIN pg_namespace n ON c.relnamespace = n.oid
+						 WHERE n.nspname = $1 AND c.relname = $2 AND c.relkind = 'v'`,
+		view.Schema, view.Name, pq.QuoteIdentifier(view.Source.Schema)+"."+pq.QuoteIdentifier(view.Source.Name))
+	var (
+		comment string
+		bound   bool
+	)
+	if err := row.Scan(&comment, &bound); err != nil {
+		return err
+	}
+
+	if comment != viewComment(view, source) || !bound {
+		return ErrWrongAttributes
+	}
+	return nil
+}
+
+// createView replaces the view in place so that views depending on it are kept,
+// the view is dropped and created when its columns can't be replaced
+func createView(ctx context.Context, tx Querier, view PgView, source PgTableDetail) error {
+	name := pq.QuoteIdentifier(view.Schema) + "." + pq.QuoteIdentifier(view.Name)
+	query := viewQuerySQL(view, source)
+
+	if _, err := tx.ExecContext(ctx, "SAVEPOINT replace_view"); err != nil {
+		return err
+	}
+	_, err := tx.ExecContext(ctx, fmt.Sprintf(`CREATE OR REPLACE VIEW %s AS %s`, name, query))
+	if err != nil {
+		statements := []string{
+			"ROLLBACK TO SAVEPOINT replace_view",
+			fmt.Sprintf(`DROP VIEW IF EXISTS %s`, name),
+			fmt.Sprintf(`CREATE VIEW %s AS %s`, name, query),
+		}
+		for _, sql := range statements {
+			if _, err = tx.ExecContext(ctx, sql); err != nil {
+				return err
+			}
+		}
+	}
+
+	statements := []string{
+		fmt.Sprintf(`COMMENT ON VIEW %s IS %s`, name, pq.QuoteLiteral(viewComment(view, source))),
+	}
+	if view.Owner != "" {
+		statements = append(statements, fmt.Sprintf(`ALTER VIEW %s OWNER TO %s`, name, pq.QuoteIdentifier(view.Owner)))
+	}
+	for _, sql := range statements {
+		if _, err = tx.ExecContext(ctx, sql); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CreateSubscriptionView (re)creates the view in a single transaction
+// so the consumers never see it missing
+func CreateSubscriptionView(ctx context.Context, db Querier, view PgView, source PgTableDetail) error {
+	return CreateSubscriptionViews(ctx, db, []PgView{view}, []PgTableDetail{source})
+}
+
+// CreateSubscriptionViews (re)creates all views in a single transaction,
+// sources are the views' replicated tables in the same order
+func CreateSubscriptionViews(ctx context.Context, db Querier, views []PgView, sources []PgTableDetail) error {
+	return InTransaction(ctx, db, func(tx Querier) error {
+		for idx, view := range views {
+			if err := createView(ctx, tx, view, sources[idx]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,62 @@
+package replication
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("viewQuerySQL", func() {
+	source := PgTableDetail{
+		PgTable: PgTable{Schema: "data", Name: "cities"},
+		Columns: []PgTableColumn{
+			{Name: "id", Type: "integer"},
+			{Name: "name", Type: "text"},
+			{Name: "zip", Type: "character varying(10)"},
+		},
+	}
+	view := PgView{
+		PgTable: PgTable{Schema: "consumer", Name: "cities"},
+		Source:  source.PgTable,
+	}
+
+	It("should select all columns", func() {
+		Expect(viewQuerySQL(view, source)).To(Equal(`SELECT "id", "name", "zip" FROM "data"."cities"`))
+	})
+
+	It("should rename and cast columns and filter rows", func() {
+		v := view
+		v.Columns = []PgViewColumn{
+			{Name: "id"},
+			{Name: "name", Alias: "city"},
+			{Name: "zip", Cast: "text"},
+		}
+		v.Filter = "zip <> ''"
+		Expect(viewQuerySQL(v, source)).To(Equal(
+			`SELECT "id", "name" AS "city", "zip"::text AS "zip" FROM "data"."cities" WHERE zip <> ''`))
+	})
+})
+
+var _ = Describe("viewComment", func() {
+	source := PgTableDetail{
+		PgTable: PgTable{Schema: "data", Name: "cities"},
+		Columns: []PgTableColumn{{Name: "id", Type: "integer"}},
+	}
+	view := PgView{
+		PgTable: PgTable{Schema: "consumer", Name: "cities"},
+		Source:  source.PgTable,
+		Columns: []PgViewColumn{{Name: "id"}},
+	}
+
+	It("should change with the source's columns", func() {
+		changed := source
+		changed.Columns = []PgTableColumn{{Name: "id", Type: "bigint"}}
+		Expect(viewComment(view, source)).To(HavePrefix(viewCommentPrefix))
+		Expect(viewComment(view, changed)).NotTo(Equal(viewComment(view, source)))
+	})
+
+	It("should change with the owner", func() {
+		owned := view
+		owned.Owner = "consumer"
+		Expect(viewComment(owned, source)).NotTo(Equal(viewComment(view, source)))
+	})
+})
@@ -17,7 +17,7 @@ INSERT INTO published_data.people VALUES
   (gen_random_uuid(), 'Your Name', 'Your Email', 1111)
   ON CONFLICT DO NOTHING;
 CREATE TABLE IF NOT EXISTS published_data.cities
-  (id UUID PRIMARY KEY, name VARCHAR(255) UNIQUE, zip VARCHAR(255), country VARCHAR(255));
+  (id UUID PRIMARY KEY, name VARCHAR(255) UNIQUE, zip VARCHAR(255) CHECK (zip <> ''), country VARCHAR(255));
 INSERT INTO published_data.cities VALUES
   (gen_random_uuid(), 'New York', '900 22', 'USA'),
   (gen_random_uuid(), 'Rio', '111 88', 'Brazil'),